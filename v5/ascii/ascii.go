@@ -0,0 +1,142 @@
+// Package ascii adapts intcode computers which read and write ASCII text
+//
+// Input is written as lines of text, each sent as character codes followed by a newline (10).
+// Output character codes are collected into lines. Values outside of the ASCII range, such as a
+// final large result, are returned separately
+package ascii
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	intcode "github.com/jblashki/aoc-intcode-go/v5"
+)
+
+//////////////////////
+// Consts and types //
+//////////////////////
+
+const newline = 10
+const maxASCII = 127
+
+// Adapter runs an intcode computer using text input and output
+type Adapter struct {
+	ic      *intcode.IntCode
+	partial strings.Builder
+}
+
+// Result is the output collected by Run
+type Result struct {
+	// Lines are the complete lines of text output, without their newlines
+	Lines []string
+	// Partial is any text output after the last newline, such as a prompt. It is not repeated by the next Run
+	Partial string
+	// Values are output values outside of the ASCII range
+	Values []int
+	// State is the state the intcode stopped in, StateInput, StateHalted or StateError
+	State intcode.State
+}
+
+////////////////////////
+// Exported functions //
+////////////////////////
+
+// Create creates an adapter for an intcode computer driven with the synchronous intcode API
+func Create(ic *intcode.IntCode) *Adapter {
+	return &Adapter{ic: ic}
+}
+
+// WriteLine queues a line of text as input followed by a newline. Returns an error if the line is not ASCII
+func WriteLine(a *Adapter, line string) error {
+	values := make([]int, 0, len(line)+1)
+	for i, r := range line {
+		if r > maxASCII {
+			return fmt.Errorf("Non-ASCII character %q at position %v", r, i)
+		}
+		values = append(values, int(r))
+	}
+	values = append(values, newline)
+
+	intcode.Feed(a.ic, values...)
+
+	return nil
+}
+
+// Run runs the intcode until it requires input, halts or fails, collecting its output
+func Run(a *Adapter) (Result, error) {
+	var result Result
+
+	for {
+		value, state, err := intcode.RunUntil(a.ic)
+
+		if state != intcode.StateOutput {
+			result.Partial = a.partial.String()
+			result.State = state
+			a.partial.Reset()
+			return result, err
+		}
+
+		switch {
+		case value == newline:
+			result.Lines = append(result.Lines, a.partial.String())
+			a.partial.Reset()
+		case value < 0 || value > maxASCII:
+			result.Values = append(result.Values, value)
+		default:
+			a.partial.WriteByte(byte(value))
+		}
+	}
+}
+
+// Bridge connects an intcode to a terminal, writing its text output to w and feeding it lines read from r
+// whenever it requires input. Non-ASCII output values are written as decimal numbers on their own line.
+// Returns when the intcode halts or fails, or r runs out of input
+func Bridge(ic *intcode.IntCode, r io.Reader, w io.Writer) error {
+	a := Create(ic)
+	scanner := bufio.NewScanner(r)
+
+	for {
+		result, err := Run(a)
+
+		for _, line := range result.Lines {
+			if _, werr := fmt.Fprintln(w, line); werr != nil {
+				return werr
+			}
+		}
+		for _, value := range result.Values {
+			if _, werr := fmt.Fprintln(w, value); werr != nil {
+				return werr
+			}
+		}
+
+		switch result.State {
+		case intcode.StateHalted:
+			if result.Partial != "" {
+				_, err = fmt.Fprintln(w, result.Partial)
+			}
+			return err
+
+		case intcode.StateError:
+			return err
+
+		case intcode.StateInput:
+			if result.Partial != "" {
+				if _, werr := fmt.Fprint(w, result.Partial); werr != nil {
+					return werr
+				}
+			}
+
+			if !scanner.Scan() {
+				if err := scanner.Err(); err != nil {
+					return err
+				}
+				return io.ErrUnexpectedEOF
+			}
+			if err := WriteLine(a, scanner.Text()); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package ascii
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	intcode "github.com/jblashki/aoc-intcode-go/v5"
+)
+
+func TestRunEcho(t *testing.T) {
+	ic, err := intcode.CreateLoad(new(sync.WaitGroup), "../test_input/TstProgASCII", 0, 0)
+	if err != nil {
+		t.Fatalf(`TestRunEcho: failed to load program: %v`, err)
+	}
+
+	a := Create(ic)
+
+	result, err := Run(a)
+	if err != nil || result.State != intcode.StateInput {
+		t.Fatalf(`TestRunEcho: returned (%v, %v), want state %v`, result.State, err, intcode.StateInput)
+	}
+
+	if err := WriteLine(a, "hello world"); err != nil {
+		t.Fatalf(`TestRunEcho: WriteLine returned error: %v`, err)
+	}
+
+	result, err = Run(a)
+	if err != nil || result.State != intcode.StateHalted {
+		t.Fatalf(`TestRunEcho: returned (%v, %v), want state %v`, result.State, err, intcode.StateHalted)
+	}
+	if len(result.Lines) != 1 || result.Lines[0] != "hello world" {
+		t.Fatalf(`TestRunEcho: returned lines %q, want ["hello world"]`, result.Lines)
+	}
+	if len(result.Values) != 1 || result.Values[0] != 1000 {
+		t.Fatalf(`TestRunEcho: returned values %v, want [1000]`, result.Values)
+	}
+
+	if err := WriteLine(a, "héllo"); err == nil {
+		t.Fatalf(`TestRunEcho: WriteLine accepted non-ASCII text`)
+	}
+}
+
+func TestBridge(t *testing.T) {
+	ic, err := intcode.CreateLoad(new(sync.WaitGroup), "../test_input/TstProgASCII", 0, 0)
+	if err != nil {
+		t.Fatalf(`TestBridge: failed to load program: %v`, err)
+	}
+
+	var out strings.Builder
+	err = Bridge(ic, strings.NewReader("ping\n"), &out)
+	if err != nil {
+		t.Fatalf(`TestBridge: returned error: %v`, err)
+	}
+
+	if out.String() != "ping\n1000\n" {
+		t.Fatalf(`TestBridge: wrote %q, want %q`, out.String(), "ping\n1000\n")
+	}
+}
+
+func TestRunPrompt(t *testing.T) {
+	ic := intcode.Create(nil, 0, 0)
+	if err := intcode.LoadString(ic, "104,63,3,50,4,50,3,50,4,50,3,50,4,50,99"); err != nil {
+		t.Fatalf(`TestRunPrompt: failed to load program: %v`, err)
+	}
+
+	a := Create(ic)
+
+	result, err := Run(a)
+	if err != nil || result.State != intcode.StateInput || result.Partial != "?" {
+		t.Fatalf(`TestRunPrompt: returned (%v, %q, %v), want state %v partial "?"`, result.State, result.Partial, err, intcode.StateInput)
+	}
+
+	WriteLine(a, "ok")
+
+	result, err = Run(a)
+	if err != nil || result.State != intcode.StateHalted {
+		t.Fatalf(`TestRunPrompt: returned (%v, %v), want state %v`, result.State, err, intcode.StateHalted)
+	}
+	if len(result.Lines) != 1 || result.Lines[0] != "ok" || result.Partial != "" {
+		t.Fatalf(`TestRunPrompt: returned lines %q partial %q, want ["ok"] and no partial`, result.Lines, result.Partial)
+	}
+}
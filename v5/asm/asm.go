@@ -0,0 +1,296 @@
+// Package asm assembles mnemonic intcode source into programs
+//
+// Source is line based. Each line may hold a label, an instruction or data directive and a comment:
+//
+//	start:  in   [x]            ; read into x
+//	        add  [x], #4, rel[3]
+//	        jt   #1, #start
+//	x:      data 0, 1, 2
+//
+// Operands are [addr] for position mode, #value for immediate mode and rel[offset] for relative mode.
+// Values may be integers, labels or a label plus or minus an integer e.g. [x+1]
+package asm
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	intcode "github.com/jblashki/aoc-intcode-go/v5"
+)
+
+//////////////////////
+// Consts and types //
+//////////////////////
+
+var labelRegex = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*):`)
+var valueRegex = regexp.MustCompile(`^(?:(-?[0-9]+)|([A-Za-z_][A-Za-z0-9_]*)(?:([+-])([0-9]+))?)$`)
+
+// Error is a diagnostic for a line of assembly source
+type Error struct {
+	// Line is the 1 based line number
+	Line int
+	// Msg describes the problem
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %v: %v", e.Line, e.Msg)
+}
+
+// ErrorList is the list of diagnostics returned when source fails to assemble
+type ErrorList []*Error
+
+func (l ErrorList) Error() string {
+	msgs := make([]string, len(l))
+	for i, err := range l {
+		msgs[i] = err.Error()
+	}
+
+	return strings.Join(msgs, "\n")
+}
+
+// operand is a parsed operand awaiting label resolution
+type operand struct {
+	mode   intcode.ParamMode
+	value  int
+	label  string
+	offset int
+}
+
+// statement is a parsed instruction or data directive
+type statement struct {
+	line     int
+	addr     int
+	op       intcode.Operation
+	data     bool
+	operands []operand
+}
+
+////////////////////////
+// Exported functions //
+////////////////////////
+
+// Assemble assembles source read from r into an intcode program
+func Assemble(r io.Reader) ([]int, error) {
+	ops := make(map[string]intcode.Operation)
+	for _, op := range intcode.Operations() {
+		ops[op.Name] = op
+	}
+
+	labels := make(map[string]int)
+	statements := make([]statement, 0)
+	errs := make(ErrorList, 0)
+	addr := 0
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+
+		text := scanner.Text()
+		if i := strings.Index(text, ";"); i >= 0 {
+			text = text[:i]
+		}
+		text = strings.TrimSpace(text)
+
+		if match := labelRegex.FindStringSubmatch(text); match != nil {
+			if _, dup := labels[match[1]]; dup {
+				errs = append(errs, &Error{lineNum, fmt.Sprintf("duplicate label %q", match[1])})
+			}
+			labels[match[1]] = addr
+			text = strings.TrimSpace(text[len(match[0]):])
+		}
+
+		if text == "" {
+			continue
+		}
+
+		mnemonic, rest := text, ""
+		if i := strings.IndexAny(text, " \t"); i >= 0 {
+			mnemonic, rest = text[:i], strings.TrimSpace(text[i:])
+		}
+		args := make([]string, 0)
+		if rest != "" {
+			for _, arg := range strings.Split(rest, ",") {
+				args = append(args, strings.TrimSpace(arg))
+			}
+		}
+
+		stmt := statement{line: lineNum, addr: addr}
+
+		if strings.ToLower(mnemonic) == "data" {
+			if len(args) == 0 {
+				errs = append(errs, &Error{lineNum, "data directive needs at least one value"})
+				continue
+			}
+
+			stmt.data = true
+			for _, arg := range args {
+				operand, err := parseValue(arg)
+				if err != nil {
+					errs = append(errs, &Error{lineNum, err.Error()})
+					continue
+				}
+				stmt.operands = append(stmt.operands, operand)
+			}
+
+			statements = append(statements, stmt)
+			addr += len(args)
+			continue
+		}
+
+		op, known := ops[strings.ToLower(mnemonic)]
+		if !known {
+			errs = append(errs, &Error{lineNum, fmt.Sprintf("unknown mnemonic %q", mnemonic)})
+			continue
+		}
+		if len(args) != op.Params {
+			errs = append(errs, &Error{lineNum, fmt.Sprintf("%v takes %v operands, got %v", op.Name, op.Params, len(args))})
+			addr += op.Params + 1
+			continue
+		}
+
+		stmt.op = op
+		for i, arg := range args {
+			operand, err := parseOperand(arg)
+			if err != nil {
+				errs = append(errs, &Error{lineNum, fmt.Sprintf("operand %v: %v", i+1, err)})
+				continue
+			}
+			if i == op.Write && operand.mode == intcode.ModeVal {
+				errs = append(errs, &Error{lineNum, fmt.Sprintf("operand %v: %v destination cannot be immediate %q", i+1, op.Name, arg)})
+				continue
+			}
+			stmt.operands = append(stmt.operands, operand)
+		}
+
+		statements = append(statements, stmt)
+		addr += op.Params + 1
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	prog := make([]int, 0, addr)
+	for _, stmt := range statements {
+		values := make([]int, 0, len(stmt.operands))
+		for _, operand := range stmt.operands {
+			value, err := resolve(operand, labels)
+			if err != nil {
+				errs = append(errs, &Error{stmt.line, err.Error()})
+			}
+			values = append(values, value)
+		}
+
+		if stmt.data {
+			prog = append(prog, values...)
+			continue
+		}
+
+		opcode := stmt.op.Code
+		scale := 100
+		for _, operand := range stmt.operands {
+			opcode += int(operand.mode) * scale
+			scale *= 10
+		}
+		prog = append(prog, opcode)
+		prog = append(prog, values...)
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return prog, nil
+}
+
+// AssembleString assembles source held in a string into an intcode program
+func AssembleString(src string) ([]int, error) {
+	return Assemble(strings.NewReader(src))
+}
+
+// WriteCSV writes a program in the comma separated format read by intcode.Load
+func WriteCSV(w io.Writer, prog []int) error {
+	values := make([]string, len(prog))
+	for i, value := range prog {
+		values[i] = strconv.Itoa(value)
+	}
+
+	_, err := fmt.Fprintln(w, strings.Join(values, ","))
+
+	return err
+}
+
+//////////////////////////
+// Unexported functions //
+//////////////////////////
+
+func parseOperand(arg string) (operand, error) {
+	var mode intcode.ParamMode
+	var inner string
+
+	switch {
+	case strings.HasPrefix(arg, "#"):
+		mode = intcode.ModeVal
+		inner = arg[1:]
+	case strings.HasPrefix(strings.ToLower(arg), "rel[") && strings.HasSuffix(arg, "]"):
+		mode = intcode.ModeRel
+		inner = arg[4 : len(arg)-1]
+	case strings.HasPrefix(arg, "[") && strings.HasSuffix(arg, "]"):
+		mode = intcode.ModePos
+		inner = arg[1 : len(arg)-1]
+	default:
+		return operand{}, fmt.Errorf("%q must be [addr], #value or rel[offset]", arg)
+	}
+
+	operand, err := parseValue(strings.TrimSpace(inner))
+	operand.mode = mode
+
+	return operand, err
+}
+
+func parseValue(arg string) (operand, error) {
+	match := valueRegex.FindStringSubmatch(arg)
+	if match == nil {
+		return operand{}, fmt.Errorf("invalid value %q", arg)
+	}
+
+	if match[1] != "" {
+		value, err := strconv.Atoi(match[1])
+		if err != nil {
+			return operand{}, fmt.Errorf("invalid value %q: %v", arg, err)
+		}
+		return operand{value: value}, nil
+	}
+
+	result := operand{label: match[2]}
+	if match[3] != "" {
+		offset, err := strconv.Atoi(match[4])
+		if err != nil {
+			return operand{}, fmt.Errorf("invalid offset %q: %v", arg, err)
+		}
+		if match[3] == "-" {
+			offset = -offset
+		}
+		result.offset = offset
+	}
+
+	return result, nil
+}
+
+func resolve(operand operand, labels map[string]int) (int, error) {
+	if operand.label == "" {
+		return operand.value, nil
+	}
+
+	addr, ok := labels[operand.label]
+	if !ok {
+		return 0, fmt.Errorf("undefined label %q", operand.label)
+	}
+
+	return addr + operand.offset, nil
+}
@@ -0,0 +1,90 @@
+package asm
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAssembleInputOutput(t *testing.T) {
+	src := `
+	; Same program as test_input/TstProgInputOutput2
+	in  [0]
+	in  [2]
+	add [0], [2], [4]
+	out [4]
+	in  [10]
+	in  [12]
+	mul [10], [12], [14]
+	out [14]
+	hlt
+`
+	prog, err := AssembleString(src)
+	if err != nil {
+		t.Fatalf(`TestAssembleInputOutput: returned error: %v`, err)
+	}
+
+	var out strings.Builder
+	if err := WriteCSV(&out, prog); err != nil {
+		t.Fatalf(`TestAssembleInputOutput: WriteCSV returned error: %v`, err)
+	}
+
+	want := "3,0,3,2,1,0,2,4,4,4,3,10,3,12,2,10,12,14,4,14,99\n"
+	if out.String() != want {
+		t.Fatalf(`TestAssembleInputOutput: returned %q, want %q`, out.String(), want)
+	}
+}
+
+func TestAssembleLabels(t *testing.T) {
+	src := `
+start:	in   rel[x]        ; relative to base 0
+	jf   rel[x], #done
+	out  #1
+	jt   #1, #start
+done:	hlt
+x:	data 7, -3, x+1
+`
+	prog, err := AssembleString(src)
+	if err != nil {
+		t.Fatalf(`TestAssembleLabels: returned error: %v`, err)
+	}
+
+	want := []int{203, 11, 1206, 11, 10, 104, 1, 1105, 1, 0, 99, 7, -3, 12}
+	if len(prog) != len(want) {
+		t.Fatalf(`TestAssembleLabels: returned %v, want %v`, prog, want)
+	}
+	for i := range want {
+		if prog[i] != want[i] {
+			t.Fatalf(`TestAssembleLabels: returned %v, want %v`, prog, want)
+		}
+	}
+}
+
+func TestAssembleDiagnostics(t *testing.T) {
+	src := `add #1, #2, #3
+	foo [1]
+	out [missing]
+	jt #1
+x:	hlt
+x:	hlt
+`
+	_, err := AssembleString(src)
+
+	var errs ErrorList
+	if !errors.As(err, &errs) {
+		t.Fatalf(`TestAssembleDiagnostics: returned %v, want ErrorList`, err)
+	}
+
+	want := []int{1, 2, 4, 6, 3}
+	if len(errs) != len(want) {
+		t.Fatalf(`TestAssembleDiagnostics: returned %v, want errors on lines %v`, err, want)
+	}
+	for i, line := range want {
+		if errs[i].Line != line {
+			t.Fatalf(`TestAssembleDiagnostics: error %v on line %v, want line %v`, errs[i], errs[i].Line, line)
+		}
+	}
+	if !strings.Contains(errs[0].Error(), "line 1: operand 3: add destination cannot be immediate") {
+		t.Fatalf(`TestAssembleDiagnostics: returned %q, want immediate destination diagnostic`, errs[0])
+	}
+}
@@ -0,0 +1,361 @@
+// Command intcode runs an intcode program from the command line
+//
+// Usage:
+//
+//	intcode [flags] program
+//
+// Inputs are taken from -input, then -input-file, then stdin as the program asks for them.
+// Each output is printed on its own line. With -ascii the program is connected to the terminal as text
+// and with -debug it is run under the interactive debugger. The exit code is 0 when the program halts,
+// 1 when it fails, 2 for usage or load errors and 3 when -timeout expires
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	intcode "github.com/jblashki/aoc-intcode-go/v5"
+	"github.com/jblashki/aoc-intcode-go/v5/ascii"
+	"github.com/jblashki/aoc-intcode-go/v5/debugger"
+)
+
+//////////////////////
+// Consts and types //
+//////////////////////
+
+const (
+	exitHalt    = 0
+	exitError   = 1
+	exitUsage   = 2
+	exitTimeout = 3
+)
+
+// patches is a repeatable addr=value flag
+type patches map[int]int
+
+func (p patches) String() string {
+	values := make([]string, 0, len(p))
+	for addr, value := range p {
+		values = append(values, fmt.Sprintf("%v=%v", addr, value))
+	}
+
+	return strings.Join(values, ",")
+}
+
+func (p patches) Set(s string) error {
+	parts := strings.SplitN(s, "=", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("patch %q must be addr=value", s)
+	}
+
+	addr, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return fmt.Errorf("patch %q has invalid address: %v", s, err)
+	}
+	value, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return fmt.Errorf("patch %q has invalid value: %v", s, err)
+	}
+
+	p[addr] = value
+
+	return nil
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+//////////////////////////
+// Unexported functions //
+//////////////////////////
+
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("intcode", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	inputFlag := flags.String("input", "", "comma separated input values")
+	inputFile := flags.String("input-file", "", "file of comma or newline separated input values")
+	prompt := flags.Bool("prompt", false, "print ? to stderr before reading input from stdin")
+	asciiMode := flags.Bool("ascii", false, "exchange ASCII text lines with the program over stdin and stdout")
+	debugMode := flags.Bool("debug", false, "run the program under the interactive debugger")
+	traceFile := flags.String("trace", "", "file to append an instruction trace to")
+	traceFormat := flags.String("trace-format", "text", "instruction trace format, text or json")
+	noun := flags.Int("noun", -1, "value to set at address 1 before running")
+	verb := flags.Int("verb", -1, "value to set at address 2 before running")
+	set := patches{}
+	flags.Var(set, "set", "addr=value to set before running, may be repeated")
+	printAddr := flags.Int("print", -1, "address to print after the program halts")
+	timeout := flags.Duration("timeout", 0, "maximum time to run for, 0 for no limit")
+	maxSteps := flags.Int("max-steps", 0, "maximum instructions to execute, 0 for no limit")
+	maxMemory := flags.Int("max-memory", 0, "maximum memory cells, 0 for no limit")
+	overflow := flags.Bool("overflow", false, "fail on integer overflow in add and mul instead of wrapping around")
+
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: intcode [flags] program")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	inputs, err := parseInts(*inputFlag)
+	if err != nil {
+		fmt.Fprintf(stderr, "intcode: -input: %v\n", err)
+		return exitUsage
+	}
+	if *inputFile != "" {
+		data, err := ioutil.ReadFile(*inputFile)
+		if err != nil {
+			fmt.Fprintf(stderr, "intcode: %v\n", err)
+			return exitUsage
+		}
+		fileInputs, err := parseInts(string(data))
+		if err != nil {
+			fmt.Fprintf(stderr, "intcode: %v: %v\n", *inputFile, err)
+			return exitUsage
+		}
+		inputs = append(inputs, fileInputs...)
+	}
+
+	wg := new(sync.WaitGroup)
+	ic, err := intcode.CreateLoad(wg, flags.Arg(0), 0, 0)
+	if err != nil {
+		fmt.Fprintf(stderr, "intcode: %v\n", err)
+		return exitUsage
+	}
+	defer intcode.Close(ic)
+
+	if *noun >= 0 {
+		set[1] = *noun
+	}
+	if *verb >= 0 {
+		set[2] = *verb
+	}
+	for addr, value := range set {
+		if err := intcode.Set(ic, addr, value); err != nil {
+			fmt.Fprintf(stderr, "intcode: %v\n", err)
+			return exitUsage
+		}
+	}
+
+	if *traceFile != "" {
+		f, err := os.OpenFile(*traceFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+		if err != nil {
+			fmt.Fprintf(stderr, "intcode: %v\n", err)
+			return exitUsage
+		}
+		defer f.Close()
+
+		switch *traceFormat {
+		case "text":
+			intcode.SetTracer(ic, intcode.NewTextTracer(f))
+		case "json":
+			intcode.SetTracer(ic, intcode.NewJSONTracer(f))
+		default:
+			fmt.Fprintf(stderr, "intcode: unknown trace format %q\n", *traceFormat)
+			return exitUsage
+		}
+	}
+
+	intcode.SetMaxSteps(ic, *maxSteps)
+	intcode.SetMaxMemory(ic, *maxMemory)
+	intcode.SetOverflowCheck(ic, *overflow)
+	intcode.Feed(ic, inputs...)
+
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	if *debugMode {
+		return runDebug(ctx, ic, stdin, stdout, stderr)
+	}
+
+	if *asciiMode {
+		return runASCII(ctx, ic, stdin, stdout, stderr)
+	}
+
+	wg.Add(1)
+	go intcode.RunContext(ctx, ic)
+
+	stdinInputs := newInputReader(stdin, stderr, *prompt)
+
+	for {
+		value, sig, err := intcode.Read(ic)
+
+		switch sig {
+		case intcode.SigNone:
+			fmt.Fprintln(stdout, value)
+
+		case intcode.SigInput:
+			select {
+			case input := <-stdinInputs.next():
+				if input.err != nil {
+					intcode.Close(ic)
+					wg.Wait()
+					fmt.Fprintf(stderr, "intcode: %v\n", input.err)
+					return exitError
+				}
+				intcode.Write(ic, input.value)
+			case <-ctx.Done():
+				intcode.Close(ic)
+				wg.Wait()
+				fmt.Fprintf(stderr, "intcode: %v\n", ctx.Err())
+				return exitTimeout
+			}
+
+		case intcode.SigHalt:
+			wg.Wait()
+			if *printAddr >= 0 {
+				fmt.Fprintln(stdout, intcode.Get(ic, *printAddr))
+			}
+			return exitHalt
+
+		case intcode.SigError:
+			wg.Wait()
+			fmt.Fprintf(stderr, "intcode: %v\n", err)
+			if errors.Is(err, context.DeadlineExceeded) {
+				return exitTimeout
+			}
+			return exitError
+		}
+	}
+}
+
+// runASCII connects an intcode to stdin and stdout as text until it halts, fails or ctx is done
+func runASCII(ctx context.Context, ic *intcode.IntCode, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	done := make(chan error, 1)
+	go func() {
+		done <- ascii.Bridge(ic, stdin, stdout)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			fmt.Fprintf(stderr, "intcode: %v\n", err)
+			return exitError
+		}
+		return exitHalt
+
+	case <-ctx.Done():
+		fmt.Fprintf(stderr, "intcode: %v\n", ctx.Err())
+		return exitTimeout
+	}
+}
+
+// runDebug runs an intcode under the interactive debugger until the session ends or ctx is done. The exit
+// code is from the state the intcode was last stopped in, so ending the session before a halt is an error
+func runDebug(ctx context.Context, ic *intcode.IntCode, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	d := debugger.Create(ic)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- debugger.REPL(d, stdin, stdout)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			fmt.Fprintf(stderr, "intcode: %v\n", err)
+			return exitError
+		}
+
+	case <-ctx.Done():
+		fmt.Fprintf(stderr, "intcode: %v\n", ctx.Err())
+		return exitTimeout
+	}
+
+	stop := debugger.Last(d)
+	switch stop.Reason {
+	case debugger.ReasonHalted:
+		return exitHalt
+	case debugger.ReasonError:
+		fmt.Fprintf(stderr, "intcode: %v\n", stop.Err)
+		return exitError
+	default:
+		fmt.Fprintf(stderr, "intcode: debugger quit at address %v before the program halted\n", intcode.Pos(ic))
+		return exitError
+	}
+}
+
+// parseInts parses integers separated by commas or whitespace
+func parseInts(s string) ([]int, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+
+	values := make([]int, len(fields))
+	for i, field := range fields {
+		value, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid input %q", field)
+		}
+		values[i] = value
+	}
+
+	return values, nil
+}
+
+// inputReader reads integers from r on demand, prompting on stderr if asked
+type inputReader struct {
+	scanner *bufio.Scanner
+	stderr  io.Writer
+	prompt  bool
+}
+
+// inputResult is the result of reading an input
+type inputResult struct {
+	value int
+	err   error
+}
+
+func newInputReader(r io.Reader, stderr io.Writer, prompt bool) *inputReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Split(bufio.ScanWords)
+
+	return &inputReader{scanner, stderr, prompt}
+}
+
+// next reads the next input in the background so the caller can give up waiting
+func (r *inputReader) next() <-chan inputResult {
+	result := make(chan inputResult, 1)
+
+	go func() {
+		if r.prompt {
+			fmt.Fprint(r.stderr, "? ")
+		}
+		if !r.scanner.Scan() {
+			err := r.scanner.Err()
+			if err == nil {
+				err = errors.New("program requested input but none is left")
+			}
+			result <- inputResult{err: err}
+			return
+		}
+
+		value, err := strconv.Atoi(strings.Trim(r.scanner.Text(), ","))
+		if err != nil {
+			err = fmt.Errorf("invalid input %q", r.scanner.Text())
+		}
+		result <- inputResult{value, err}
+	}()
+
+	return result
+}
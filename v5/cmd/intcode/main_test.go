@@ -0,0 +1,106 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRunInputs(t *testing.T) {
+	var stdout, stderr strings.Builder
+	code := run([]string{"-input", "5,3", "../../test_input/TstProgInputOutput2"}, strings.NewReader("10 4"), &stdout, &stderr)
+	if code != exitHalt {
+		t.Fatalf(`TestRunInputs: returned %v, want %v: %v`, code, exitHalt, stderr.String())
+	}
+
+	if stdout.String() != "8\n40\n" {
+		t.Fatalf(`TestRunInputs: printed %q, want %q`, stdout.String(), "8\n40\n")
+	}
+}
+
+func TestRunPatch(t *testing.T) {
+	var stdout, stderr strings.Builder
+	code := run([]string{"-noun", "0", "-verb", "4", "-print", "0", "../../test_input/TstProg1"}, strings.NewReader(""), &stdout, &stderr)
+	if code != exitHalt {
+		t.Fatalf(`TestRunPatch: returned %v, want %v: %v`, code, exitHalt, stderr.String())
+	}
+
+	if stdout.String() != "100\n" {
+		t.Fatalf(`TestRunPatch: printed %q, want %q`, stdout.String(), "100\n")
+	}
+}
+
+func TestRunExitCodes(t *testing.T) {
+	tests := []struct {
+		args []string
+		want int
+	}{
+		{[]string{"../../test_input/TstProgInvalidOp"}, exitError},
+		{[]string{"../../test_input/TstProgInputOutput"}, exitError},
+		{[]string{"-timeout", "20ms", "../../test_input/TstProgLoop"}, exitTimeout},
+		{[]string{"-max-steps", "100", "../../test_input/TstProgLoop"}, exitError},
+		{[]string{"../../test_input/Missing"}, exitUsage},
+		{[]string{}, exitUsage},
+	}
+
+	for _, test := range tests {
+		var stdout, stderr strings.Builder
+		code := run(test.args, strings.NewReader(""), &stdout, &stderr)
+		if code != test.want {
+			t.Fatalf(`TestRunExitCodes: %v returned %v, want %v: %v`, test.args, code, test.want, stderr.String())
+		}
+	}
+}
+
+func TestRunASCII(t *testing.T) {
+	var stdout, stderr strings.Builder
+	code := run([]string{"-ascii", "../../test_input/TstProgASCII"}, strings.NewReader("hi there\n"), &stdout, &stderr)
+	if code != exitHalt {
+		t.Fatalf(`TestRunASCII: returned %v, want %v: %v`, code, exitHalt, stderr.String())
+	}
+
+	if stdout.String() != "hi there\n1000\n" {
+		t.Fatalf(`TestRunASCII: printed %q, want %q`, stdout.String(), "hi there\n1000\n")
+	}
+}
+
+func TestRunDebug(t *testing.T) {
+	var stdout, stderr strings.Builder
+	code := run([]string{"-debug", "-input", "7", "../../test_input/TstProgInputOutput"}, strings.NewReader("continue\nquit\n"), &stdout, &stderr)
+	if code != exitHalt {
+		t.Fatalf(`TestRunDebug: returned %v, want %v: %v`, code, exitHalt, stderr.String())
+	}
+
+	if !strings.Contains(stdout.String(), "output 7\nhalted\n") {
+		t.Fatalf(`TestRunDebug: printed %q, want output 7 then halted`, stdout.String())
+	}
+}
+
+func TestRunDebugExitCodes(t *testing.T) {
+	tests := []struct {
+		program  string
+		commands string
+		want     int
+	}{
+		{"TstProgInputOutput", "step\nquit\n", exitError},
+		{"TstProgInvalidOp", "continue\nquit\n", exitError},
+		{"TstProgInputOutput", "", exitError},
+	}
+
+	for _, test := range tests {
+		var stdout, stderr strings.Builder
+		code := run([]string{"-debug", "-input", "7", "../../test_input/" + test.program}, strings.NewReader(test.commands), &stdout, &stderr)
+		if code != test.want {
+			t.Fatalf(`TestRunDebugExitCodes: %v with %q returned %v, want %v`, test.program, test.commands, code, test.want)
+		}
+	}
+
+	stdin, w := io.Pipe()
+	defer w.Close()
+
+	var stdout, stderr strings.Builder
+	code := run([]string{"-debug", "-timeout", "50ms", "../../test_input/TstProgInputOutput"}, stdin, &stdout, &stderr)
+	if code != exitTimeout {
+		t.Fatalf(`TestRunDebugExitCodes: returned %v after timeout, want %v`, code, exitTimeout)
+	}
+}
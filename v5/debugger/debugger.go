@@ -0,0 +1,227 @@
+// Package debugger provides breakpoints, watchpoints and single stepping for intcode computers
+//
+// A Debugger drives an intcode with the synchronous intcode API. Memory, the program position and
+// the relative base can be inspected and changed with the intcode package functions between stops
+package debugger
+
+import (
+	"sort"
+
+	intcode "github.com/jblashki/aoc-intcode-go/v5"
+	"github.com/jblashki/aoc-intcode-go/v5/disasm"
+)
+
+//////////////////////
+// Consts and types //
+//////////////////////
+
+// Reason is the reason a debugger stopped
+type Reason int
+
+const (
+	// ReasonStep means a single step completed
+	ReasonStep Reason = iota
+	// ReasonBreakpoint means the program position reached a breakpoint
+	ReasonBreakpoint
+	// ReasonWatch means a watched memory cell changed
+	ReasonWatch
+	// ReasonInput means the intcode requires input which can be queued with intcode.Feed
+	ReasonInput
+	// ReasonHalted means the intcode halted
+	ReasonHalted
+	// ReasonError means the intcode failed
+	ReasonError
+)
+
+// Stop describes why and where a debugger stopped
+type Stop struct {
+	// Reason is why the debugger stopped
+	Reason Reason
+	// Pos is the program position after stopping
+	Pos int
+	// Watch is the watched address which changed for ReasonWatch
+	Watch int
+	// Old is the previous value of the watched address for ReasonWatch
+	Old int
+	// New is the new value of the watched address for ReasonWatch
+	New int
+	// Outputs are the values output since the last stop
+	Outputs []int
+	// Err is the error the intcode failed with for ReasonError
+	Err error
+}
+
+// Debugger controls execution of an intcode computer
+type Debugger struct {
+	ic          *intcode.IntCode
+	breakpoints map[int]bool
+	watches     map[int]int
+	last        Stop
+}
+
+////////////////////////
+// Exported functions //
+////////////////////////
+
+// Create creates a debugger for an intcode computer
+func Create(ic *intcode.IntCode) *Debugger {
+	return &Debugger{
+		ic:          ic,
+		breakpoints: make(map[int]bool),
+		watches:     make(map[int]int),
+	}
+}
+
+// IntCode returns the intcode computer being debugged
+func IntCode(d *Debugger) *intcode.IntCode {
+	return d.ic
+}
+
+// Break sets a breakpoint at an address
+func Break(d *Debugger, addr int) {
+	d.breakpoints[addr] = true
+}
+
+// Clear removes a breakpoint from an address
+func Clear(d *Debugger, addr int) {
+	delete(d.breakpoints, addr)
+}
+
+// Breakpoints returns the breakpoint addresses in order
+func Breakpoints(d *Debugger) []int {
+	return sortedKeys(d.breakpoints)
+}
+
+// Watch stops execution whenever the value at an address changes
+func Watch(d *Debugger, addr int) {
+	d.watches[addr] = intcode.Get(d.ic, addr)
+}
+
+// Unwatch removes a watchpoint from an address
+func Unwatch(d *Debugger, addr int) {
+	delete(d.watches, addr)
+}
+
+// Watches returns the watched addresses in order
+func Watches(d *Debugger) []int {
+	addrs := make(map[int]bool, len(d.watches))
+	for addr := range d.watches {
+		addrs[addr] = true
+	}
+
+	return sortedKeys(addrs)
+}
+
+// Step executes a single instruction
+func Step(d *Debugger) Stop {
+	stop := Stop{Reason: ReasonStep}
+
+	value, state, err := intcode.Step(d.ic)
+	if state == intcode.StateOutput {
+		stop.Outputs = append(stop.Outputs, value)
+	}
+
+	d.last = finish(d, stop, state, err)
+
+	return d.last
+}
+
+// Continue executes instructions until a breakpoint or watchpoint is hit, input is required,
+// or the intcode halts or fails. The instruction at the current position is always executed
+// so continuing from a breakpoint makes progress
+func Continue(d *Debugger) Stop {
+	stop := Stop{Reason: ReasonStep}
+
+	for {
+		value, state, err := intcode.Step(d.ic)
+		if state == intcode.StateOutput {
+			stop.Outputs = append(stop.Outputs, value)
+		}
+
+		stop = finish(d, stop, state, err)
+		if stop.Reason != ReasonStep {
+			d.last = stop
+			return stop
+		}
+
+		if d.breakpoints[stop.Pos] {
+			stop.Reason = ReasonBreakpoint
+			d.last = stop
+			return stop
+		}
+	}
+}
+
+// Last returns the most recent stop from Step or Continue. Before either is called it is a ReasonStep stop
+func Last(d *Debugger) Stop {
+	return d.last
+}
+
+// List disassembles count instructions starting at the current program position
+func List(d *Debugger, count int) []disasm.Line {
+	lines := make([]disasm.Line, 0, count)
+
+	// Decode in place so listing costs the same however large memory is
+	addr := intcode.Pos(d.ic)
+	for len(lines) < count && addr >= 0 && addr < intcode.Size(d.ic) {
+		inst, err := intcode.DecodeAt(d.ic, addr)
+		if err != nil {
+			lines = append(lines, disasm.Line{Addr: addr, Data: []int{intcode.Get(d.ic, addr)}})
+			addr++
+			continue
+		}
+
+		lines = append(lines, disasm.Line{Addr: addr, Code: true, Inst: inst})
+		addr += len(inst.Params) + 1
+	}
+
+	return lines
+}
+
+//////////////////////////
+// Unexported functions //
+//////////////////////////
+
+// finish fills in a stop after an instruction has been stepped
+func finish(d *Debugger, stop Stop, state intcode.State, err error) Stop {
+	stop.Pos = intcode.Pos(d.ic)
+
+	switch state {
+	case intcode.StateInput:
+		stop.Reason = ReasonInput
+		return stop
+	case intcode.StateHalted:
+		stop.Reason = ReasonHalted
+		return stop
+	case intcode.StateError:
+		stop.Reason = ReasonError
+		stop.Err = err
+		return stop
+	}
+
+	// Report the lowest changed address but record every change so it is only reported once
+	for _, addr := range Watches(d) {
+		value := intcode.Get(d.ic, addr)
+		if value != d.watches[addr] {
+			if stop.Reason != ReasonWatch {
+				stop.Reason = ReasonWatch
+				stop.Watch = addr
+				stop.Old = d.watches[addr]
+				stop.New = value
+			}
+			d.watches[addr] = value
+		}
+	}
+
+	return stop
+}
+
+func sortedKeys(set map[int]bool) []int {
+	keys := make([]int, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+
+	return keys
+}
@@ -0,0 +1,105 @@
+package debugger
+
+import (
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	intcode "github.com/jblashki/aoc-intcode-go/v5"
+)
+
+func TestBreakAndWatch(t *testing.T) {
+	ic, err := intcode.CreateLoad(new(sync.WaitGroup), "../test_input/TstProgInputOutput2", 0, 0)
+	if err != nil {
+		t.Fatalf(`TestBreakAndWatch: failed to load program: %v`, err)
+	}
+
+	d := Create(ic)
+	Break(d, 8)
+	Watch(d, 14)
+
+	stop := Continue(d)
+	if stop.Reason != ReasonInput || stop.Pos != 0 {
+		t.Fatalf(`TestBreakAndWatch: stopped for %v at %v, want %v at 0`, stop.Reason, stop.Pos, ReasonInput)
+	}
+
+	intcode.Feed(ic, 5, 3, 10, 4)
+
+	stop = Continue(d)
+	if stop.Reason != ReasonBreakpoint || stop.Pos != 8 {
+		t.Fatalf(`TestBreakAndWatch: stopped for %v at %v, want %v at 8`, stop.Reason, stop.Pos, ReasonBreakpoint)
+	}
+
+	stop = Step(d)
+	if stop.Reason != ReasonStep || len(stop.Outputs) != 1 || stop.Outputs[0] != 8 {
+		t.Fatalf(`TestBreakAndWatch: step returned %v with outputs %v, want %v with [8]`, stop.Reason, stop.Outputs, ReasonStep)
+	}
+
+	stop = Continue(d)
+	if stop.Reason != ReasonWatch || stop.Watch != 14 || stop.Old != 2 || stop.New != 40 {
+		t.Fatalf(`TestBreakAndWatch: stopped with %+v, want watch on 14 changing 2 => 40`, stop)
+	}
+
+	stop = Continue(d)
+	if stop.Reason != ReasonHalted || len(stop.Outputs) != 1 || stop.Outputs[0] != 40 {
+		t.Fatalf(`TestBreakAndWatch: stopped with %+v, want halt with outputs [40]`, stop)
+	}
+}
+
+func TestREPL(t *testing.T) {
+	ic, err := intcode.CreateLoad(new(sync.WaitGroup), "../test_input/TstProgInputOutput2", 0, 0)
+	if err != nil {
+		t.Fatalf(`TestREPL: failed to load program: %v`, err)
+	}
+
+	commands := strings.Join([]string{
+		"break 8",
+		"input 5 3",
+		"continue",
+		"list 2",
+		"set 4 99",
+		"step",
+		"regs",
+		"quit",
+	}, "\n")
+
+	var out strings.Builder
+	if err := REPL(Create(ic), strings.NewReader(commands), &out); err != nil {
+		t.Fatalf(`TestREPL: returned error: %v`, err)
+	}
+
+	for _, want := range []string{
+		"breakpoint at 8\n=* 0008: OUT [4]\n",
+		"=* 0008: OUT [4]\n   0010: IN [10]\n",
+		"output 99\n",
+		"pc 10 rb 0 steps 4\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf(`TestREPL: wrote %q, want it to contain %q`, out.String(), want)
+		}
+	}
+}
+
+func TestListLargeMemory(t *testing.T) {
+	ic := intcode.CreateWithMemory(new(sync.WaitGroup), intcode.NewSparseMemory(nil), 0, 0)
+	if err := intcode.LoadString(ic, "104,7,99"); err != nil {
+		t.Fatalf(`TestListLargeMemory: failed to load program: %v`, err)
+	}
+	intcode.Set(ic, 1<<40, 1)
+
+	d := Create(ic)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	lines := List(d, 2)
+	runtime.ReadMemStats(&after)
+
+	if len(lines) != 2 || lines[0].Addr != 0 || lines[1].Addr != 2 {
+		t.Fatalf(`TestListLargeMemory: listed %+v, want instructions at 0 and 2`, lines)
+	}
+
+	if used := after.TotalAlloc - before.TotalAlloc; used > 1<<20 {
+		t.Fatalf(`TestListLargeMemory: listing allocated %v bytes`, used)
+	}
+}
@@ -0,0 +1,218 @@
+package debugger
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	intcode "github.com/jblashki/aoc-intcode-go/v5"
+	"github.com/jblashki/aoc-intcode-go/v5/disasm"
+)
+
+const listCount = 5
+
+const replHelp = `commands:
+  s, step [n]          execute n instructions (default 1)
+  c, continue          run until a breakpoint, watchpoint, input, halt or error
+  b, break addr        set a breakpoint
+  d, delete addr       remove a breakpoint
+  w, watch addr        stop when the value at addr changes
+  u, unwatch addr      remove a watchpoint
+  m, mem addr [n]      print n memory cells from addr (default 1)
+  set addr value       set a memory cell
+  pc [addr]            print or set the program position
+  rb [value]           print or set the relative base
+  r, regs              print the program position, relative base and steps
+  l, list [n]          disassemble n instructions from the program position (default 5)
+  i, input v...        queue input values
+  h, help              print this help
+  q, quit              leave the debugger
+`
+
+// REPL runs an interactive debugger session reading commands from r and writing results to w.
+// Returns when the quit command is given or r runs out of input
+func REPL(d *Debugger, r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+
+	for {
+		fmt.Fprint(w, "(icdb) ")
+		if !scanner.Scan() {
+			fmt.Fprintln(w)
+			return scanner.Err()
+		}
+
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		args, err := parseArgs(fields[1:])
+		if err != nil {
+			fmt.Fprintln(w, err)
+			continue
+		}
+
+		switch fields[0] {
+		case "s", "step":
+			count := 1
+			if len(args) > 0 {
+				count = args[0]
+			}
+			for i := 0; i < count; i++ {
+				stop := Step(d)
+				printStop(d, w, stop)
+				if stop.Reason != ReasonStep && stop.Reason != ReasonWatch {
+					break
+				}
+			}
+
+		case "c", "continue":
+			printStop(d, w, Continue(d))
+
+		case "b", "break":
+			if !requireArgs(w, args, 1, "break addr") {
+				continue
+			}
+			Break(d, args[0])
+
+		case "d", "delete":
+			if !requireArgs(w, args, 1, "delete addr") {
+				continue
+			}
+			Clear(d, args[0])
+
+		case "w", "watch":
+			if !requireArgs(w, args, 1, "watch addr") {
+				continue
+			}
+			Watch(d, args[0])
+
+		case "u", "unwatch":
+			if !requireArgs(w, args, 1, "unwatch addr") {
+				continue
+			}
+			Unwatch(d, args[0])
+
+		case "m", "mem":
+			if !requireArgs(w, args, 1, "mem addr [n]") {
+				continue
+			}
+			count := 1
+			if len(args) > 1 {
+				count = args[1]
+			}
+			for addr := args[0]; addr < args[0]+count; addr++ {
+				fmt.Fprintf(w, "%04d: %v\n", addr, intcode.Get(d.ic, addr))
+			}
+
+		case "set":
+			if !requireArgs(w, args, 2, "set addr value") {
+				continue
+			}
+			if err := intcode.Set(d.ic, args[0], args[1]); err != nil {
+				fmt.Fprintln(w, err)
+				continue
+			}
+			if _, watched := d.watches[args[0]]; watched {
+				Watch(d, args[0])
+			}
+
+		case "pc":
+			if len(args) > 0 {
+				intcode.SetPos(d.ic, args[0])
+			}
+			fmt.Fprintf(w, "pc %v\n", intcode.Pos(d.ic))
+
+		case "rb":
+			if len(args) > 0 {
+				intcode.SetRelativeBase(d.ic, args[0])
+			}
+			fmt.Fprintf(w, "rb %v\n", intcode.RelativeBase(d.ic))
+
+		case "r", "regs":
+			fmt.Fprintf(w, "pc %v rb %v steps %v\n", intcode.Pos(d.ic), intcode.RelativeBase(d.ic), intcode.Steps(d.ic))
+
+		case "l", "list":
+			count := listCount
+			if len(args) > 0 {
+				count = args[0]
+			}
+			printList(d, w, count)
+
+		case "i", "input":
+			intcode.Feed(d.ic, args...)
+
+		case "h", "help":
+			fmt.Fprint(w, replHelp)
+
+		case "q", "quit":
+			return nil
+
+		default:
+			fmt.Fprintf(w, "unknown command %q, try help\n", fields[0])
+		}
+	}
+}
+
+func parseArgs(fields []string) ([]int, error) {
+	args := make([]int, len(fields))
+	for i, field := range fields {
+		value, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", field)
+		}
+		args[i] = value
+	}
+
+	return args, nil
+}
+
+func requireArgs(w io.Writer, args []int, count int, usage string) bool {
+	if len(args) < count {
+		fmt.Fprintf(w, "usage: %v\n", usage)
+		return false
+	}
+
+	return true
+}
+
+func printStop(d *Debugger, w io.Writer, stop Stop) {
+	for _, value := range stop.Outputs {
+		fmt.Fprintf(w, "output %v\n", value)
+	}
+
+	switch stop.Reason {
+	case ReasonBreakpoint:
+		fmt.Fprintf(w, "breakpoint at %v\n", stop.Pos)
+	case ReasonWatch:
+		fmt.Fprintf(w, "watch %v changed %v => %v\n", stop.Watch, stop.Old, stop.New)
+	case ReasonInput:
+		fmt.Fprintln(w, "waiting for input")
+	case ReasonHalted:
+		fmt.Fprintln(w, "halted")
+		return
+	case ReasonError:
+		fmt.Fprintf(w, "error: %v\n", stop.Err)
+		return
+	}
+
+	printList(d, w, 1)
+}
+
+func printList(d *Debugger, w io.Writer, count int) {
+	pos := intcode.Pos(d.ic)
+
+	for _, line := range List(d, count) {
+		marker := "  "
+		if line.Addr == pos {
+			marker = "=>"
+		}
+		if d.breakpoints[line.Addr] {
+			marker = marker[:1] + "*"
+		}
+
+		fmt.Fprintf(w, "%v %v\n", marker, disasm.FormatLine(line))
+	}
+}
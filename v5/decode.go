@@ -0,0 +1,117 @@
+package intcode
+
+import "sort"
+
+// Operation describes an operation supported by an intcode computer
+type Operation struct {
+	// Code is the operation code without parameter modes
+	Code int
+	// Name is the mnemonic of the operation
+	Name string
+	// Params is the number of parameters the operation takes
+	Params int
+	// Write is the index of the parameter written to or -1 if none
+	Write int
+}
+
+// Instruction is a decoded intcode instruction
+type Instruction struct {
+	// Addr is the address of the instruction
+	Addr int
+	// Opcode is the raw opcode including parameter modes
+	Opcode int
+	// Op is the operation of the instruction
+	Op Operation
+	// Params are the raw parameter values
+	Params []int
+	// Modes are the parameter modes
+	Modes []ParamMode
+}
+
+// Operations returns the operations supported by an intcode computer ordered by code
+func Operations() []Operation {
+	ops := make([]Operation, 0, len(opTable))
+	for _, op := range opTable {
+		ops = append(ops, op)
+	}
+
+	sort.Slice(ops, func(i, j int) bool {
+		return ops[i].Code < ops[j].Code
+	})
+
+	return ops
+}
+
+// LookupOperation returns the operation for an opcode, ignoring any parameter modes
+func LookupOperation(opcode int) (Operation, bool) {
+	op, known := opTable[opcode%100]
+
+	return op, known
+}
+
+// Decode decodes the instruction at addr in a memory image
+func Decode(mem []int, addr int) (Instruction, error) {
+	return decode(NewDenseMemory(mem), addr)
+}
+
+// DecodeAt decodes the instruction at addr in the memory of an intcode without copying it
+func DecodeAt(ic *IntCode, addr int) (Instruction, error) {
+	return decode(ic.memory, addr)
+}
+
+func decode(mem Memory, addr int) (Instruction, error) {
+	op, err := validate(mem, addr)
+	if err != nil {
+		inst := Instruction{Addr: addr}
+		if addr >= 0 && addr < mem.Size() {
+			inst.Opcode = mem.Get(addr)
+			inst.Op = op
+		}
+
+		return inst, err
+	}
+
+	inst := Instruction{Addr: addr, Opcode: mem.Get(addr), Op: op}
+	inst.Params = make([]int, op.Params)
+	inst.Modes = make([]ParamMode, op.Params)
+	for i := 0; i < op.Params; i++ {
+		inst.Params[i] = mem.Get(addr + 1 + i)
+		inst.Modes[i] = getParamMode(inst.Opcode, i)
+	}
+
+	return inst, nil
+}
+
+// validate checks the instruction at addr can be executed without decoding it, so allocates nothing unless it fails
+func validate(mem Memory, addr int) (Operation, error) {
+	size := mem.Size()
+	if addr < 0 || addr >= size {
+		return Operation{}, decodeFault(mem, &ProgramCounterError{Pos: addr, Size: size}, addr)
+	}
+
+	opcode := mem.Get(addr)
+	op, known := LookupOperation(opcode)
+	if !known {
+		return op, decodeFault(mem, &UnknownOpcodeError{}, addr)
+	}
+
+	if addr+op.Params >= size {
+		return op, decodeFault(mem, &ProgramCounterError{Pos: size, Size: size}, addr)
+	}
+
+	for i := 0; i < op.Params; i++ {
+		mode := getParamMode(opcode, i)
+		if mode > ModeRel || (i == op.Write && mode == ModeVal) {
+			return op, decodeFault(mem, &InvalidModeError{Param: i, Mode: mode}, addr)
+		}
+	}
+
+	return op, nil
+}
+
+// decodeFault fills in the fault details of err for the instruction at addr in memory
+func decodeFault(mem Memory, err error, addr int) error {
+	ic := IntCode{memory: mem}
+
+	return newFault(&ic, err, addr)
+}
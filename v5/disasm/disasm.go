@@ -0,0 +1,194 @@
+// Package disasm disassembles intcode memory images into mnemonic listings
+package disasm
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	intcode "github.com/jblashki/aoc-intcode-go/v5"
+)
+
+//////////////////////
+// Consts and types //
+//////////////////////
+
+// maxDataPerLine is the maximum number of data values grouped on one line
+const maxDataPerLine = 8
+
+// Line is a line of a disassembly listing, either an instruction or a run of data values
+type Line struct {
+	// Addr is the address of the first cell of the line
+	Addr int
+	// Code is true when the line is an instruction reachable from address 0
+	Code bool
+	// Inst is the decoded instruction when Code is true
+	Inst intcode.Instruction
+	// Data are the raw values when Code is false
+	Data []int
+}
+
+////////////////////////
+// Exported functions //
+////////////////////////
+
+// Disassemble disassembles a memory image. Instructions are found by following control flow from address 0
+// through fall through and immediate jump targets; anything not reached is treated as data
+func Disassemble(mem []int) []Line {
+	starts := reachable(mem)
+
+	lines := make([]Line, 0)
+	addr := 0
+	for addr < len(mem) {
+		if inst, ok := starts[addr]; ok {
+			lines = append(lines, Line{Addr: addr, Code: true, Inst: inst})
+			addr += len(inst.Params) + 1
+			continue
+		}
+
+		if len(lines) > 0 {
+			last := &lines[len(lines)-1]
+			if !last.Code && len(last.Data) < maxDataPerLine {
+				last.Data = append(last.Data, mem[addr])
+				addr++
+				continue
+			}
+		}
+
+		lines = append(lines, Line{Addr: addr, Data: []int{mem[addr]}})
+		addr++
+	}
+
+	return lines
+}
+
+// Linear disassembles up to count instructions starting at addr without following control flow.
+// Cells which do not decode as instructions become single data values
+func Linear(mem []int, addr int, count int) []Line {
+	lines := make([]Line, 0, count)
+
+	for len(lines) < count && addr >= 0 && addr < len(mem) {
+		inst, err := intcode.Decode(mem, addr)
+		if err != nil {
+			lines = append(lines, Line{Addr: addr, Data: []int{mem[addr]}})
+			addr++
+			continue
+		}
+
+		lines = append(lines, Line{Addr: addr, Code: true, Inst: inst})
+		addr += len(inst.Params) + 1
+	}
+
+	return lines
+}
+
+// Format renders an instruction as mnemonic text e.g. ADD [12], #4, rel[3]
+func Format(inst intcode.Instruction) string {
+	params := make([]string, len(inst.Params))
+	for i, param := range inst.Params {
+		params[i] = formatParam(param, inst.Modes[i])
+	}
+
+	text := strings.ToUpper(inst.Op.Name)
+	if len(params) > 0 {
+		text += " " + strings.Join(params, ", ")
+	}
+
+	return text
+}
+
+// FormatLine renders a listing line with its address
+func FormatLine(line Line) string {
+	if line.Code {
+		return fmt.Sprintf("%04d: %v", line.Addr, Format(line.Inst))
+	}
+
+	values := make([]string, len(line.Data))
+	for i, value := range line.Data {
+		values[i] = fmt.Sprint(value)
+	}
+
+	return fmt.Sprintf("%04d: DATA %v", line.Addr, strings.Join(values, ", "))
+}
+
+// Render writes a listing to w, one line per instruction or data run
+func Render(w io.Writer, lines []Line) error {
+	for _, line := range lines {
+		_, err := fmt.Fprintln(w, FormatLine(line))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//////////////////////////
+// Unexported functions //
+//////////////////////////
+
+func formatParam(param int, mode intcode.ParamMode) string {
+	switch mode {
+	case intcode.ModeVal:
+		return fmt.Sprintf("#%v", param)
+	case intcode.ModeRel:
+		return fmt.Sprintf("rel[%v]", param)
+	default:
+		return fmt.Sprintf("[%v]", param)
+	}
+}
+
+// reachable returns the instructions reachable from address 0 keyed by address
+func reachable(mem []int) map[int]intcode.Instruction {
+	starts := make(map[int]intcode.Instruction)
+	pending := []int{0}
+
+	for len(pending) > 0 {
+		addr := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		for {
+			if _, seen := starts[addr]; seen {
+				break
+			}
+
+			inst, err := intcode.Decode(mem, addr)
+			if err != nil {
+				break
+			}
+			starts[addr] = inst
+
+			if inst.Op.Name == "hlt" {
+				break
+			}
+
+			if inst.Op.Name == "jt" || inst.Op.Name == "jf" {
+				taken, always := jumpTaken(inst)
+				if taken && inst.Modes[1] == intcode.ModeVal {
+					pending = append(pending, inst.Params[1])
+				}
+				if always {
+					break
+				}
+			}
+
+			addr += len(inst.Params) + 1
+		}
+	}
+
+	return starts
+}
+
+// jumpTaken reports whether a jump may be taken and whether it is always taken
+func jumpTaken(inst intcode.Instruction) (taken bool, always bool) {
+	if inst.Modes[0] != intcode.ModeVal {
+		return true, false
+	}
+
+	nonZero := inst.Params[0] != 0
+	if inst.Op.Name == "jf" {
+		nonZero = !nonZero
+	}
+
+	return nonZero, nonZero
+}
@@ -0,0 +1,44 @@
+package disasm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDisassemble(t *testing.T) {
+	// Add, jump over data, output then halt
+	mem := []int{21101, 12, 4, 3, 1105, 1, 9, 42, 43, 204, -1, 99, 7}
+
+	var out strings.Builder
+	err := Render(&out, Disassemble(mem))
+	if err != nil {
+		t.Fatalf(`TestDisassemble: returned error: %v`, err)
+	}
+
+	want := "0000: ADD #12, #4, rel[3]\n" +
+		"0004: JT #1, #9\n" +
+		"0007: DATA 42, 43\n" +
+		"0009: OUT rel[-1]\n" +
+		"0011: HLT\n" +
+		"0012: DATA 7\n"
+
+	if out.String() != want {
+		t.Fatalf(`TestDisassemble: returned %q, want %q`, out.String(), want)
+	}
+}
+
+func TestLinear(t *testing.T) {
+	mem := []int{1, 12, 4, 3, 98, 99}
+
+	lines := Linear(mem, 0, 3)
+	if len(lines) != 3 {
+		t.Fatalf(`TestLinear: returned %v lines, want 3`, len(lines))
+	}
+
+	want := []string{"0000: ADD [12], [4], [3]", "0004: DATA 98", "0005: HLT"}
+	for i, line := range lines {
+		if FormatLine(line) != want[i] {
+			t.Fatalf(`TestLinear: line %v returned %q, want %q`, i, FormatLine(line), want[i])
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package intcode
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// DumpFormat is the layout used by Dump
+type DumpFormat int
+
+const (
+	// DumpCSV is comma separated values on one line, readable by Load
+	DumpCSV DumpFormat = iota
+	// DumpJSON is a JSON array
+	DumpJSON
+	// DumpTable is a table of dumpColumns values per row with address columns and printable characters
+	DumpTable
+)
+
+// dumpColumns is the number of values in each row of a DumpTable
+const dumpColumns = 8
+
+// Save writes the memory of an intcode to file as CSV which can be read back with Load
+func Save(ic *IntCode, file string) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+
+	if err := Dump(ic, f, DumpCSV, false); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// Dump writes the memory of an intcode to w in format. With trim, trailing zeros are left out, such as those
+// created when Set grows memory, though never those of the loaded program. Memory is read run by run, so sparse memory is never expanded; the table
+// format also collapses repeated rows of zeros into a single * line
+func Dump(ic *IntCode, w io.Writer, format DumpFormat, trim bool) error {
+	c := newCellCursor(ic.memory)
+	n := ic.memory.Size()
+	if trim {
+		n = c.lastNonZero() + 1
+		if ic.program != nil && n < ic.program.Len() {
+			n = ic.program.Len()
+		}
+	}
+
+	switch format {
+	case DumpCSV:
+		return writeList(w, c, n, "", "\n")
+	case DumpJSON:
+		return writeList(w, c, n, "[", "]\n")
+	case DumpTable:
+		return writeTable(w, c, n)
+	default:
+		return fmt.Errorf("Unknown dump format %v", format)
+	}
+}
+
+// cellCursor reads memory cells in address order from the runs stored, without allocating the gaps between them
+type cellCursor struct {
+	runs []segment
+	run  int
+	addr int
+}
+
+func newCellCursor(mem Memory) *cellCursor {
+	c := new(cellCursor)
+	mem.Range(func(addr int, values []int) bool {
+		c.runs = append(c.runs, segment{addr, values})
+		return true
+	})
+
+	return c
+}
+
+// lastNonZero returns the highest address holding a value other than 0, or -1 if there is none
+func (c *cellCursor) lastNonZero() int {
+	for i := len(c.runs) - 1; i >= 0; i-- {
+		values := c.runs[i].Values
+		for j := len(values) - 1; j >= 0; j-- {
+			if values[j] != 0 {
+				return c.runs[i].Addr + j
+			}
+		}
+	}
+
+	return -1
+}
+
+// width returns the widest value below n when written in decimal
+func (c *cellCursor) width(n int) int {
+	width := 1
+	for _, run := range c.runs {
+		for i, value := range run.Values {
+			if run.Addr+i >= n {
+				break
+			}
+			if w := len(strconv.Itoa(value)); w > width {
+				width = w
+			}
+		}
+	}
+
+	return width
+}
+
+// seek moves past runs ending at or before the current address
+func (c *cellCursor) seek() {
+	for c.run < len(c.runs) && c.runs[c.run].Addr+len(c.runs[c.run].Values) <= c.addr {
+		c.run++
+	}
+}
+
+// next returns the value at the current address and moves on to the next
+func (c *cellCursor) next() int {
+	c.seek()
+
+	value := 0
+	if c.run < len(c.runs) && c.runs[c.run].Addr <= c.addr {
+		value = c.runs[c.run].Values[c.addr-c.runs[c.run].Addr]
+	}
+	c.addr++
+
+	return value
+}
+
+// zeros returns the number of cells from the current address up to end which are not stored, so are 0
+func (c *cellCursor) zeros(end int) int {
+	c.seek()
+
+	if c.run < len(c.runs) && c.runs[c.run].Addr < end {
+		if c.runs[c.run].Addr <= c.addr {
+			return 0
+		}
+		return c.runs[c.run].Addr - c.addr
+	}
+
+	return end - c.addr
+}
+
+// writeList writes n values separated by commas between prefix and suffix
+func writeList(w io.Writer, c *cellCursor, n int, prefix string, suffix string) error {
+	bw := bufio.NewWriter(w)
+
+	bw.WriteString(prefix)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			bw.WriteByte(',')
+		}
+		bw.WriteString(strconv.Itoa(c.next()))
+	}
+	bw.WriteString(suffix)
+
+	return bw.Flush()
+}
+
+// writeTable writes rows of an address, the values from it and the values which are printable ASCII
+func writeTable(w io.Writer, c *cellCursor, n int) error {
+	bw := bufio.NewWriter(w)
+
+	width := c.width(n)
+	addrWidth := len(strconv.Itoa(n))
+	if addrWidth < 4 {
+		addrWidth = 4
+	}
+
+	fmt.Fprintf(bw, "%*s ", addrWidth, "addr")
+	for i := 0; i < dumpColumns; i++ {
+		fmt.Fprintf(bw, " %*s", width, "+"+strconv.Itoa(i))
+	}
+	bw.WriteByte('\n')
+
+	lastZero, starred := false, false
+	star := func() {
+		if !starred {
+			bw.WriteString("*\n")
+			starred = true
+		}
+	}
+
+	row := 0
+	for row < n {
+		// Skip whole rows of a gap at once after the first row of zeros
+		if z := c.zeros(n); lastZero && z >= dumpColumns {
+			skip := z - z%dumpColumns
+			c.addr += skip
+			row += skip
+			star()
+			continue
+		}
+
+		values := make([]int, 0, dumpColumns)
+		zero := true
+		for i := row; i < row+dumpColumns && i < n; i++ {
+			value := c.next()
+			values = append(values, value)
+			zero = zero && value == 0
+		}
+		zero = zero && len(values) == dumpColumns
+
+		if zero && lastZero {
+			star()
+			row += dumpColumns
+			continue
+		}
+
+		fmt.Fprintf(bw, "%0*d:", addrWidth, row)
+		text := make([]byte, 0, dumpColumns)
+		for i := 0; i < dumpColumns; i++ {
+			if i >= len(values) {
+				fmt.Fprintf(bw, " %*s", width, "")
+				continue
+			}
+
+			fmt.Fprintf(bw, " %*d", width, values[i])
+			if values[i] >= ' ' && values[i] <= '~' {
+				text = append(text, byte(values[i]))
+			} else {
+				text = append(text, '.')
+			}
+		}
+		fmt.Fprintf(bw, "  |%s|\n", text)
+
+		lastZero, starred = zero, false
+		row += dumpColumns
+	}
+
+	return bw.Flush()
+}
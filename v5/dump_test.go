@@ -0,0 +1,113 @@
+package intcode
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveLoad(t *testing.T) {
+	ic, err := CreateLoad(nil, "./test_input/TstProg4", 0, 0)
+	if err != nil {
+		t.Fatalf(`TestSaveLoad: failed to load program: %v`, err)
+	}
+	Set(ic, 20, -7)
+
+	dir, err := ioutil.TempDir("", "intcode")
+	if err != nil {
+		t.Fatalf(`TestSaveLoad: failed to create directory: %v`, err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "saved")
+	if err := Save(ic, file); err != nil {
+		t.Fatalf(`TestSaveLoad: failed to save: %v`, err)
+	}
+
+	loaded, err := CreateLoad(nil, file, 0, 0)
+	if err != nil {
+		t.Fatalf(`TestSaveLoad: failed to load saved program: %v`, err)
+	}
+	if Size(loaded) != Size(ic) {
+		t.Fatalf(`TestSaveLoad: loaded %v values, want %v`, Size(loaded), Size(ic))
+	}
+	for addr := 0; addr < Size(ic); addr++ {
+		if Get(loaded, addr) != Get(ic, addr) {
+			t.Fatalf(`TestSaveLoad: address %v is %v, want %v`, addr, Get(loaded, addr), Get(ic, addr))
+		}
+	}
+}
+
+func TestDumpFormats(t *testing.T) {
+	ic := Create(nil, 0, 0)
+	if err := LoadString(ic, "104,72,104,-105,99"); err != nil {
+		t.Fatalf(`TestDumpFormats: failed to load program: %v`, err)
+	}
+	Set(ic, 9, 0)
+
+	tests := []struct {
+		format DumpFormat
+		trim   bool
+		want   string
+	}{
+		{DumpCSV, false, "104,72,104,-105,99,0,0,0,0,0\n"},
+		{DumpCSV, true, "104,72,104,-105,99\n"},
+		{DumpJSON, true, "[104,72,104,-105,99]\n"},
+		{DumpTable, true, "" +
+			"addr    +0   +1   +2   +3   +4   +5   +6   +7\n" +
+			"0000:  104   72  104 -105   99                 |hHh.c|\n"},
+		{DumpTable, false, "" +
+			"addr    +0   +1   +2   +3   +4   +5   +6   +7\n" +
+			"0000:  104   72  104 -105   99    0    0    0  |hHh.c...|\n" +
+			"0008:    0    0                                |..|\n"},
+	}
+
+	for i, test := range tests {
+		var buf bytes.Buffer
+		if err := Dump(ic, &buf, test.format, test.trim); err != nil {
+			t.Fatalf(`TestDumpFormats: test %v returned error: %v`, i, err)
+		}
+		if buf.String() != test.want {
+			t.Fatalf(`TestDumpFormats: test %v returned %q, want %q`, i, buf.String(), test.want)
+		}
+	}
+
+	var buf bytes.Buffer
+	Dump(ic, &buf, DumpJSON, false)
+	var values []int
+	if err := json.Unmarshal(buf.Bytes(), &values); err != nil || len(values) != 10 {
+		t.Fatalf(`TestDumpFormats: JSON returned %v values, error %v, want 10`, len(values), err)
+	}
+
+	if err := Dump(ic, &buf, DumpFormat(-1), false); err == nil {
+		t.Fatalf(`TestDumpFormats: unknown format returned no error`)
+	}
+}
+
+func TestDumpTrimProgram(t *testing.T) {
+	ic := Create(nil, 0, 0)
+	if err := LoadString(ic, "104,0"); err != nil {
+		t.Fatalf(`TestDumpTrimProgram: failed to load program: %v`, err)
+	}
+	Set(ic, 10, 0)
+
+	var buf bytes.Buffer
+	if err := Dump(ic, &buf, DumpCSV, true); err != nil {
+		t.Fatalf(`TestDumpTrimProgram: returned error: %v`, err)
+	}
+	if buf.String() != "104,0\n" {
+		t.Fatalf(`TestDumpTrimProgram: wrote %q, want "104,0\n"`, buf.String())
+	}
+
+	loaded := Create(nil, 0, 0)
+	if err := LoadString(loaded, buf.String()); err != nil {
+		t.Fatalf(`TestDumpTrimProgram: failed to load dump: %v`, err)
+	}
+	value, state, err := RunUntil(loaded)
+	if err != nil || state != StateOutput || value != 0 {
+		t.Fatalf(`TestDumpTrimProgram: returned (%v, %v, %v), want (0, %v, nil)`, value, state, err, StateOutput)
+	}
+}
@@ -0,0 +1,130 @@
+package intcode
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrClosed is returned when an intcode has been closed
+var ErrClosed = errors.New("Intcode closed")
+
+// ErrNoProgram is returned by Reset when an intcode has not been loaded with a program
+var ErrNoProgram = errors.New("Intcode has no program to reset to")
+
+// ErrInputExhausted is returned by functions from Func when the program asks for more input than given
+var ErrInputExhausted = errors.New("Program needs more input than given")
+
+// Fault describes the state of an intcode computer at the instruction that failed
+type Fault struct {
+	// Addr is the address of the failing instruction
+	Addr int
+	// Opcode is the raw opcode of the failing instruction including parameter modes
+	Opcode int
+	// Modes are the parameter modes decoded from the opcode
+	Modes []ParamMode
+	// RelativeBase is the relative base when the instruction failed
+	RelativeBase int
+}
+
+func (f *Fault) setFault(fault Fault) {
+	*f = fault
+}
+
+// faulter is implemented by errors which carry a Fault
+type faulter interface {
+	setFault(fault Fault)
+}
+
+// UnknownOpcodeError is returned when an instruction has an unknown operation
+type UnknownOpcodeError struct {
+	Fault
+}
+
+func (e *UnknownOpcodeError) Error() string {
+	return fmt.Sprintf("Unknown operation %v at address %v", e.Opcode%100, e.Addr)
+}
+
+// InvalidModeError is returned when a parameter has an unknown mode or an output parameter is in value mode
+type InvalidModeError struct {
+	Fault
+	// Param is the index of the parameter with the invalid mode
+	Param int
+	// Mode is the invalid mode
+	Mode ParamMode
+}
+
+func (e *InvalidModeError) Error() string {
+	return fmt.Sprintf("Invalid mode %v for parameter %v of operation %v at address %v", e.Mode, e.Param+1, e.Opcode, e.Addr)
+}
+
+// NegativeAddressError is returned when a negative address is read or written
+type NegativeAddressError struct {
+	Fault
+	// Target is the negative address that was accessed
+	Target int
+}
+
+func (e *NegativeAddressError) Error() string {
+	return fmt.Sprintf("Negative address %v @ address %v", e.Target, e.Addr)
+}
+
+// ProgramCounterError is returned when the program counter runs outside of memory
+type ProgramCounterError struct {
+	Fault
+	// Pos is the position that was outside of memory
+	Pos int
+	// Size is the size of memory when the program counter went out of bounds
+	Size int
+}
+
+func (e *ProgramCounterError) Error() string {
+	return fmt.Sprintf("Program counter %v out of bounds for memory of size %v @ address %v", e.Pos, e.Size, e.Addr)
+}
+
+// InputClosedError is returned when input is required but the input channel has been closed
+type InputClosedError struct {
+	Fault
+}
+
+func (e *InputClosedError) Error() string {
+	return fmt.Sprintf("Input channel closed @ address %v", e.Addr)
+}
+
+// Unwrap returns ErrClosed
+func (e *InputClosedError) Unwrap() error {
+	return ErrClosed
+}
+
+// BudgetError is returned when an intcode exceeds its instruction or memory budget
+type BudgetError struct {
+	Fault
+	// Steps is the number of instructions executed
+	Steps int
+	// MaxSteps is the instruction budget, 0 if unlimited
+	MaxSteps int
+	// Memory is the memory size reached or requested
+	Memory int
+	// MaxMemory is the memory budget, 0 if unlimited
+	MaxMemory int
+}
+
+func (e *BudgetError) Error() string {
+	if e.MaxSteps > 0 && e.Steps >= e.MaxSteps {
+		return fmt.Sprintf("Instruction budget exceeded after %v of %v instructions @ address %v", e.Steps, e.MaxSteps, e.Addr)
+	}
+
+	return fmt.Sprintf("Memory budget exceeded growing to %v of %v cells @ address %v", e.Memory, e.MaxMemory, e.Addr)
+}
+
+// OverflowError is returned when an arithmetic instruction overflows with overflow checking enabled
+type OverflowError struct {
+	Fault
+	// Name is the mnemonic of the operation which overflowed
+	Name string
+	// A and B are the operands
+	A, B int
+}
+
+func (e *OverflowError) Error() string {
+	return fmt.Sprintf("Integer overflow in %v %v, %v @ address %v", e.Name, e.A, e.B, e.Addr)
+}
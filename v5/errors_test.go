@@ -0,0 +1,224 @@
+package intcode
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestErrorUnknownOpcode(t *testing.T) {
+	err := testProgram("./test_input/TstProgInvalidOp", 0, 0)
+
+	var opErr *UnknownOpcodeError
+	if !errors.As(err, &opErr) {
+		t.Fatalf(`TestErrorUnknownOpcode: returned %v, want UnknownOpcodeError`, err)
+	}
+	if opErr.Addr != 0 || opErr.Opcode != 98 {
+		t.Fatalf(`TestErrorUnknownOpcode: fault at address %v opcode %v, want address 0 opcode 98`, opErr.Addr, opErr.Opcode)
+	}
+}
+
+func TestErrorInvalidMode(t *testing.T) {
+	err := testProgram("./test_input/TstProgInvalidMode", 0, 0)
+
+	var modeErr *InvalidModeError
+	if !errors.As(err, &modeErr) {
+		t.Fatalf(`TestErrorInvalidMode: returned %v, want InvalidModeError`, err)
+	}
+	if modeErr.Param != 2 || modeErr.Mode != ModeVal {
+		t.Fatalf(`TestErrorInvalidMode: returned param %v mode %v, want param 2 mode %v`, modeErr.Param, modeErr.Mode, ModeVal)
+	}
+	want := []ParamMode{ModePos, ModePos, ModeVal}
+	for i := range want {
+		if modeErr.Modes[i] != want[i] {
+			t.Fatalf(`TestErrorInvalidMode: returned modes %v, want %v`, modeErr.Modes, want)
+		}
+	}
+}
+
+func TestErrorNegativeAddress(t *testing.T) {
+	wg := new(sync.WaitGroup)
+	ic, err := CreateLoad(wg, "./test_input/TstProgNegativeAddr", 0, 0)
+	if err != nil {
+		t.Fatalf(`TestErrorNegativeAddress: failed to load program: %v`, err)
+	}
+
+	_, state, err := Step(ic)
+	if state != StateError {
+		t.Fatalf(`TestErrorNegativeAddress: returned state %v, want %v`, state, StateError)
+	}
+
+	var addrErr *NegativeAddressError
+	if !errors.As(err, &addrErr) {
+		t.Fatalf(`TestErrorNegativeAddress: returned %v, want NegativeAddressError`, err)
+	}
+	if addrErr.Target != -1 || addrErr.Addr != 0 || addrErr.Opcode != 1101 {
+		t.Fatalf(`TestErrorNegativeAddress: returned target %v @ address %v opcode %v, want -1 @ 0 opcode 1101`,
+			addrErr.Target, addrErr.Addr, addrErr.Opcode)
+	}
+
+	err = Set(ic, -5, 1)
+	if !errors.As(err, &addrErr) || addrErr.Target != -5 {
+		t.Fatalf(`TestErrorNegativeAddress: Set returned %v, want NegativeAddressError for -5`, err)
+	}
+}
+
+func TestErrorNoHalt(t *testing.T) {
+	err := testProgram("./test_input/TstProgNoHalt", 0, 0)
+
+	var pcErr *ProgramCounterError
+	if !errors.As(err, &pcErr) {
+		t.Fatalf(`TestErrorNoHalt: returned %v, want ProgramCounterError`, err)
+	}
+	if pcErr.Pos != 4 || pcErr.Size != 4 {
+		t.Fatalf(`TestErrorNoHalt: returned position %v size %v, want position 4 size 4`, pcErr.Pos, pcErr.Size)
+	}
+}
+
+func TestErrorTruncated(t *testing.T) {
+	err := testProgram("./test_input/TstProgTruncated", 0, 0)
+
+	var pcErr *ProgramCounterError
+	if !errors.As(err, &pcErr) {
+		t.Fatalf(`TestErrorTruncated: returned %v, want ProgramCounterError`, err)
+	}
+	if pcErr.Addr != 0 || pcErr.Opcode != 1101 {
+		t.Fatalf(`TestErrorTruncated: fault at address %v opcode %v, want address 0 opcode 1101`, pcErr.Addr, pcErr.Opcode)
+	}
+}
+
+func TestErrorNegativeRead(t *testing.T) {
+	err := testProgram("./test_input/TstProgNegativeRead", 0, 0)
+
+	var addrErr *NegativeAddressError
+	if !errors.As(err, &addrErr) {
+		t.Fatalf(`TestErrorNegativeRead: returned %v, want NegativeAddressError`, err)
+	}
+	if addrErr.Target != -1 {
+		t.Fatalf(`TestErrorNegativeRead: returned target %v, want -1`, addrErr.Target)
+	}
+}
+
+func TestErrorStepBudget(t *testing.T) {
+	wg := new(sync.WaitGroup)
+	ic, err := CreateLoad(wg, "./test_input/TstProgLoop", 0, 0)
+	if err != nil {
+		t.Fatalf(`TestErrorStepBudget: failed to load program: %v`, err)
+	}
+	defer Close(ic)
+
+	SetMaxSteps(ic, 1000)
+
+	wg.Add(1)
+	go Run(ic, "")
+
+	_, sig, err := Read(ic)
+	wg.Wait()
+
+	var budgetErr *BudgetError
+	if sig != SigError || !errors.As(err, &budgetErr) {
+		t.Fatalf(`TestErrorStepBudget: returned (%v, %v), want BudgetError`, sig, err)
+	}
+	if budgetErr.Steps != 1000 || budgetErr.MaxSteps != 1000 || budgetErr.Addr != 0 {
+		t.Fatalf(`TestErrorStepBudget: returned %v steps of %v @ address %v, want 1000 of 1000 @ 0`,
+			budgetErr.Steps, budgetErr.MaxSteps, budgetErr.Addr)
+	}
+}
+
+func TestErrorMemoryBudget(t *testing.T) {
+	wg := new(sync.WaitGroup)
+	ic, err := CreateLoad(wg, "./test_input/TstProgGrow", 0, 0)
+	if err != nil {
+		t.Fatalf(`TestErrorMemoryBudget: failed to load program: %v`, err)
+	}
+
+	SetMaxMemory(ic, 1000)
+
+	_, state, err := RunUntil(ic)
+
+	var budgetErr *BudgetError
+	if state != StateError || !errors.As(err, &budgetErr) {
+		t.Fatalf(`TestErrorMemoryBudget: returned (%v, %v), want BudgetError`, state, err)
+	}
+	if budgetErr.Memory != 1001 || budgetErr.MaxMemory != 1000 {
+		t.Fatalf(`TestErrorMemoryBudget: returned memory %v of %v, want 1001 of 1000`, budgetErr.Memory, budgetErr.MaxMemory)
+	}
+
+	SetMaxMemory(ic, 0)
+
+	_, state, err = RunUntil(ic)
+	if state != StateHalted || err != nil {
+		t.Fatalf(`TestErrorMemoryBudget: returned (%v, %v) without budget, want (%v, nil)`, state, err, StateHalted)
+	}
+	if Steps(ic) != 1 {
+		t.Fatalf(`TestErrorMemoryBudget: executed %v instructions, want 1`, Steps(ic))
+	}
+}
+
+func TestErrorOverflow(t *testing.T) {
+	wg := new(sync.WaitGroup)
+	ic, err := CreateLoad(wg, "./test_input/TstProgOverflow", 0, 0)
+	if err != nil {
+		t.Fatalf(`TestErrorOverflow: failed to load program: %v`, err)
+	}
+	SetOverflowCheck(ic, true)
+
+	_, state, err := RunUntil(ic)
+
+	var overflowErr *OverflowError
+	if state != StateError || !errors.As(err, &overflowErr) {
+		t.Fatalf(`TestErrorOverflow: returned %v %v, want %v OverflowError`, state, err, StateError)
+	}
+	if overflowErr.Addr != 0 || overflowErr.Opcode != 2 || overflowErr.Name != "mul" {
+		t.Fatalf(`TestErrorOverflow: fault at address %v opcode %v in %v, want address 0 opcode 2 in mul`, overflowErr.Addr, overflowErr.Opcode, overflowErr.Name)
+	}
+	if overflowErr.A != overflowErr.B || overflowErr.A != Get(ic, 9) {
+		t.Fatalf(`TestErrorOverflow: operands %v and %v, want %v`, overflowErr.A, overflowErr.B, Get(ic, 9))
+	}
+}
+
+func TestErrorOverflowUnchecked(t *testing.T) {
+	wg := new(sync.WaitGroup)
+	ic, err := CreateLoad(wg, "./test_input/TstProgOverflow", 0, 0)
+	if err != nil {
+		t.Fatalf(`TestErrorOverflowUnchecked: failed to load program: %v`, err)
+	}
+	SetMaxSteps(ic, 100)
+
+	_, _, err = RunUntil(ic)
+
+	var budgetErr *BudgetError
+	if !errors.As(err, &budgetErr) {
+		t.Fatalf(`TestErrorOverflowUnchecked: returned %v, want BudgetError`, err)
+	}
+}
+
+func TestOverflowBoundaries(t *testing.T) {
+	const maxInt = -(minInt + 1)
+	ic := Create(nil, 0, 0)
+	SetOverflowCheck(ic, true)
+
+	tests := []struct {
+		op       func(*IntCode, int, int) (int, error)
+		a, b     int
+		overflow bool
+	}{
+		{add, maxInt, 1, true},
+		{add, maxInt, 0, false},
+		{add, minInt, -1, true},
+		{add, minInt, maxInt, false},
+		{mul, maxInt, 2, true},
+		{mul, maxInt, -1, false},
+		{mul, minInt, -1, true},
+		{mul, -1, minInt, true},
+		{mul, minInt, 1, false},
+		{mul, 0, minInt, false},
+	}
+
+	for i, test := range tests {
+		_, err := test.op(ic, test.a, test.b)
+		if (err != nil) != test.overflow {
+			t.Fatalf(`TestOverflowBoundaries: test %v returned %v, want overflow %v`, i, err, test.overflow)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package intcode
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Function is an intcode program called like a Go function: the inputs are fed in and the outputs
+// produced before it halts are returned
+type Function func(inputs ...int) ([]int, error)
+
+// Func wraps the memory of an intcode as a Function. Each call runs a fresh instance of the memory as it is
+// when Func is called, with the same instruction, memory and overflow limits. With memoize, outputs are
+// remembered by input and returned without running again. The Function may be called concurrently
+func Func(ic *IntCode, memoize bool) Function {
+	// Clone keeps the backend of ic, so sparse memory is not expanded
+	p := &Program{ic.memory.Clone()}
+	maxSteps, maxMemory, overflow := ic.maxSteps, ic.maxMemory, ic.overflow
+
+	call := func(inputs ...int) ([]int, error) {
+		m := Instantiate(p, nil, 0, 0)
+		SetMaxSteps(m, maxSteps)
+		SetMaxMemory(m, maxMemory)
+		SetOverflowCheck(m, overflow)
+
+		for _, input := range inputs {
+			Feed(m, input)
+		}
+
+		outputs := make([]int, 0)
+		for {
+			value, state, err := RunUntil(m)
+			if err != nil {
+				return nil, fmt.Errorf("Program error: %w", err)
+			}
+
+			switch state {
+			case StateOutput:
+				outputs = append(outputs, value)
+			case StateInput:
+				return nil, ErrInputExhausted
+			case StateHalted:
+				return outputs, nil
+			}
+		}
+	}
+
+	if !memoize {
+		return call
+	}
+
+	var mutex sync.Mutex
+	memo := make(map[string][]int)
+
+	return func(inputs ...int) ([]int, error) {
+		key := memoKey(inputs)
+
+		mutex.Lock()
+		outputs, found := memo[key]
+		mutex.Unlock()
+
+		if !found {
+			var err error
+			outputs, err = call(inputs...)
+			if err != nil {
+				return nil, err
+			}
+
+			mutex.Lock()
+			memo[key] = outputs
+			mutex.Unlock()
+		}
+
+		return append([]int{}, outputs...), nil
+	}
+}
+
+// memoKey returns the memo key of an input tuple
+func memoKey(inputs []int) string {
+	var key strings.Builder
+	for _, input := range inputs {
+		key.WriteString(strconv.Itoa(input))
+		key.WriteByte(',')
+	}
+
+	return key.String()
+}
@@ -0,0 +1,88 @@
+package intcode
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestFunc(t *testing.T) {
+	ic, err := CreateLoad(nil, "./test_input/TstProgAmp", 0, 0)
+	if err != nil {
+		t.Fatalf(`TestFunc: failed to load program: %v`, err)
+	}
+
+	for _, memoize := range []bool{false, true} {
+		amp := Func(ic, memoize)
+
+		signal := 0
+		for _, phase := range []int{4, 3, 2, 1, 0} {
+			outputs, err := amp(phase, signal)
+			if err != nil || len(outputs) != 1 {
+				t.Fatalf(`TestFunc: returned %v %v, want one output`, outputs, err)
+			}
+			signal = outputs[0]
+		}
+
+		if signal != 43210 {
+			t.Fatalf(`TestFunc: memoize %v returned %v, want 43210`, memoize, signal)
+		}
+
+		outputs, _ := amp(4, 0)
+		outputs[0] = -1
+		if outputs, _ = amp(4, 0); outputs[0] != 4 {
+			t.Fatalf(`TestFunc: memoize %v returned %v after changing a result, want [4]`, memoize, outputs)
+		}
+	}
+}
+
+func TestFuncConcurrent(t *testing.T) {
+	ic, err := CreateLoad(nil, "./test_input/TstProgAmp", 0, 0)
+	if err != nil {
+		t.Fatalf(`TestFuncConcurrent: failed to load program: %v`, err)
+	}
+	amp := Func(ic, true)
+	Set(ic, 5, 15)
+
+	var wg sync.WaitGroup
+	results := make([][]int, 40)
+	errs := make([]error, 40)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = amp(i%4, i%10)
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range results {
+		want := i%4 + 10*(i%10)
+		if errs[i] != nil || len(results[i]) != 1 || results[i][0] != want {
+			t.Fatalf(`TestFuncConcurrent: call %v returned %v %v, want [%v]`, i, results[i], errs[i], want)
+		}
+	}
+}
+
+func TestFuncErrors(t *testing.T) {
+	ic, err := CreateLoad(nil, "./test_input/TstProgAmp", 0, 0)
+	if err != nil {
+		t.Fatalf(`TestFuncErrors: failed to load program: %v`, err)
+	}
+	amp := Func(ic, true)
+
+	if _, err := amp(1); err != ErrInputExhausted {
+		t.Fatalf(`TestFuncErrors: returned %v, want ErrInputExhausted`, err)
+	}
+
+	loop, err := CreateLoad(nil, "./test_input/TstProgLoop", 0, 0)
+	if err != nil {
+		t.Fatalf(`TestFuncErrors: failed to load program: %v`, err)
+	}
+	SetMaxSteps(loop, 50)
+
+	var budgetErr *BudgetError
+	if _, err := Func(loop, false)(); !errors.As(err, &budgetErr) {
+		t.Fatalf(`TestFuncErrors: returned %v, want BudgetError`, err)
+	}
+}
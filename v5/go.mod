@@ -1,5 +1,3 @@
 module github.com/jblashki/aoc-intcode-go/v5
 
 go 1.14
-
-require github.com/jblashki/aoc-filereader-go v1.0.0
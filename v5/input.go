@@ -0,0 +1,207 @@
+package intcode
+
+import (
+	"context"
+	"sync"
+)
+
+// InputProvider supplies values for input instructions once the values queued with Feed are used up
+type InputProvider interface {
+	// Input returns the next input value without blocking. ok is false when no value is available yet, in which
+	// case the intcode stops with StateInput, or in channel mode signals SigInput and reads from Write
+	Input() (value int, ok bool, err error)
+}
+
+// inputWaiter is implemented by providers whose values arrive from other goroutines. In channel mode the
+// intcode waits on them instead of signalling SigInput
+type inputWaiter interface {
+	// waitInput blocks until a value arrives, returning it with ok true, or until the provider is closed,
+	// ctx is done or done is closed, returning ok false
+	waitInput(ctx context.Context, done <-chan struct{}) (value int, ok bool)
+}
+
+// SliceInput provides a fixed list of values in order
+type SliceInput struct {
+	values []int
+}
+
+// ChanInput provides values received from a channel. In channel mode the intcode waits for a value
+type ChanInput struct {
+	ch <-chan int
+}
+
+// Queue provides values pushed to it. It is safe to push from other goroutines while the intcode runs,
+// and in channel mode the intcode waits for a value to be pushed or the queue to be closed
+type Queue struct {
+	mutex  sync.Mutex
+	ready  chan struct{}
+	values []int
+	closed bool
+}
+
+// InputFunc provides values by calling a function
+type InputFunc func() (int, error)
+
+// defaultInput provides a default value whenever another provider has none
+type defaultInput struct {
+	provider InputProvider
+	value    int
+}
+
+// SetInput sets the provider consulted by input instructions when no values are queued. nil removes it
+func SetInput(ic *IntCode, provider InputProvider) {
+	ic.provider = provider
+}
+
+// NewSliceInput creates a provider returning values in order
+func NewSliceInput(values ...int) *SliceInput {
+	return &SliceInput{append([]int(nil), values...)}
+}
+
+// Input returns the next value, ok is false once all values are used
+func (p *SliceInput) Input() (int, bool, error) {
+	if len(p.values) == 0 {
+		return 0, false, nil
+	}
+
+	value := p.values[0]
+	p.values = p.values[1:]
+
+	return value, true, nil
+}
+
+// NewChanInput creates a provider reading from ch
+func NewChanInput(ch <-chan int) *ChanInput {
+	return &ChanInput{ch}
+}
+
+// Input returns a value if one has been sent, ok is false otherwise. Returns InputClosedError once the channel is closed
+func (p *ChanInput) Input() (int, bool, error) {
+	select {
+	case value, ok := <-p.ch:
+		if !ok {
+			return 0, false, &InputClosedError{}
+		}
+		return value, true, nil
+	default:
+		return 0, false, nil
+	}
+}
+
+func (p *ChanInput) waitInput(ctx context.Context, done <-chan struct{}) (int, bool) {
+	select {
+	case value, ok := <-p.ch:
+		return value, ok
+	case <-ctx.Done():
+	case <-done:
+	}
+
+	return 0, false
+}
+
+// NewQueue creates an empty queue
+func NewQueue() *Queue {
+	return &Queue{ready: make(chan struct{}, 1)}
+}
+
+// Push adds values to the end of the queue
+func (q *Queue) Push(values ...int) {
+	q.mutex.Lock()
+	q.values = append(q.values, values...)
+	q.mutex.Unlock()
+
+	q.notify()
+}
+
+// Close closes the queue. Values already pushed can still be read
+func (q *Queue) Close() {
+	q.mutex.Lock()
+	q.closed = true
+	q.mutex.Unlock()
+
+	q.notify()
+}
+
+// Input returns the next value, ok is false if none has been pushed. Returns InputClosedError once the queue is
+// closed and empty
+func (q *Queue) Input() (int, bool, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if len(q.values) == 0 {
+		if q.closed {
+			return 0, false, &InputClosedError{}
+		}
+		return 0, false, nil
+	}
+
+	value := q.values[0]
+	q.values = q.values[1:]
+
+	return value, true, nil
+}
+
+func (q *Queue) waitInput(ctx context.Context, done <-chan struct{}) (int, bool) {
+	for {
+		value, ok, err := q.Input()
+		if ok || err != nil {
+			return value, ok
+		}
+
+		select {
+		case <-q.ready:
+		case <-ctx.Done():
+			return 0, false
+		case <-done:
+			return 0, false
+		}
+	}
+}
+
+// notify wakes a waiting intcode without blocking. One pending wake up is enough as it rechecks the queue
+func (q *Queue) notify() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// Input calls the function for the next value. The function should not block as it cannot be cancelled
+func (f InputFunc) Input() (int, bool, error) {
+	value, err := f()
+
+	return value, err == nil, err
+}
+
+// DefaultInput wraps a provider so value is returned whenever it has nothing available, as polling programs expect.
+// provider may be nil to always return value
+func DefaultInput(provider InputProvider, value int) InputProvider {
+	return &defaultInput{provider, value}
+}
+
+// Input returns the wrapped provider's next value or the default
+func (p *defaultInput) Input() (int, bool, error) {
+	if p.provider != nil {
+		value, ok, err := p.provider.Input()
+		if ok || err != nil {
+			return value, ok, err
+		}
+	}
+
+	return p.value, true, nil
+}
+
+// nextInput returns the next queued input, or asks the input provider if nothing is queued
+func nextInput(ic *IntCode) (int, bool, error) {
+	if len(ic.input) > 0 {
+		value := ic.input[0]
+		ic.input = ic.input[1:]
+		return value, true, nil
+	}
+
+	if ic.provider == nil {
+		return 0, false, nil
+	}
+
+	return ic.provider.Input()
+}
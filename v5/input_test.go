@@ -0,0 +1,182 @@
+package intcode
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSliceInput(t *testing.T) {
+	ic, err := CreateLoad(new(sync.WaitGroup), "./test_input/TstProgInputOutput2", 0, 0)
+	if err != nil {
+		t.Fatalf(`TestSliceInput: failed to load program: %v`, err)
+	}
+
+	SetInput(ic, NewSliceInput(3, 10, 4))
+	Feed(ic, 5)
+
+	outputs := make([]int, 0)
+	for {
+		value, state, err := RunUntil(ic)
+		if err != nil || state == StateInput {
+			t.Fatalf(`TestSliceInput: returned (%v, %v), want output or halt`, state, err)
+		} else if state == StateHalted {
+			break
+		}
+		outputs = append(outputs, value)
+	}
+
+	if len(outputs) != 2 || outputs[0] != 8 || outputs[1] != 40 {
+		t.Fatalf(`TestSliceInput: returned %v, want [8 40]`, outputs)
+	}
+}
+
+func TestDefaultInput(t *testing.T) {
+	ic, err := CreateLoad(new(sync.WaitGroup), "./test_input/TstProgPoll", 0, 0)
+	if err != nil {
+		t.Fatalf(`TestDefaultInput: failed to load program: %v`, err)
+	}
+
+	SetInput(ic, DefaultInput(NewSliceInput(), -1))
+
+	value, state, err := RunUntil(ic)
+	if err != nil || state != StateOutput || value != 1 {
+		t.Fatalf(`TestDefaultInput: returned (%v, %v, %v), want (1, %v, nil)`, value, state, err, StateOutput)
+	}
+}
+
+func TestQueueInput(t *testing.T) {
+	ic, err := CreateLoad(new(sync.WaitGroup), "./test_input/TstProgInputOutput2", 0, 0)
+	if err != nil {
+		t.Fatalf(`TestQueueInput: failed to load program: %v`, err)
+	}
+
+	queue := NewQueue()
+	SetInput(ic, queue)
+
+	if _, state, err := RunUntil(ic); err != nil || state != StateInput {
+		t.Fatalf(`TestQueueInput: returned (%v, %v), want %v`, state, err, StateInput)
+	}
+
+	queue.Push(5, 3)
+	queue.Push(10)
+	queue.Close()
+
+	value, state, err := RunUntil(ic)
+	if err != nil || state != StateOutput || value != 8 {
+		t.Fatalf(`TestQueueInput: returned (%v, %v, %v), want (8, %v, nil)`, value, state, err, StateOutput)
+	}
+
+	_, state, err = RunUntil(ic)
+
+	var closedErr *InputClosedError
+	if state != StateError || !errors.As(err, &closedErr) {
+		t.Fatalf(`TestQueueInput: returned (%v, %v), want InputClosedError`, state, err)
+	}
+	if closedErr.Addr != 12 {
+		t.Fatalf(`TestQueueInput: failed @ address %v, want 12`, closedErr.Addr)
+	}
+}
+
+func TestQueueInputRun(t *testing.T) {
+	wg := new(sync.WaitGroup)
+	ic, err := CreateLoad(wg, "./test_input/TstProgInputOutput", 0, 0)
+	if err != nil {
+		t.Fatalf(`TestQueueInputRun: failed to load program: %v`, err)
+	}
+	defer Close(ic)
+
+	queue := NewQueue()
+	SetInput(ic, queue)
+
+	wg.Add(1)
+	go Run(ic, "")
+
+	time.Sleep(10 * time.Millisecond)
+	queue.Push(42)
+
+	value, sig, err := Read(ic)
+	if err != nil || sig != SigNone || value != 42 {
+		t.Fatalf(`TestQueueInputRun: returned (%v, %v, %v), want (42, %v, nil)`, value, sig, err, SigNone)
+	}
+
+	if _, sig, _ := Read(ic); sig != SigHalt {
+		t.Fatalf(`TestQueueInputRun: returned %v, want %v`, sig, SigHalt)
+	}
+	wg.Wait()
+}
+
+func TestInputCancel(t *testing.T) {
+	providers := map[string]InputProvider{
+		"chan":  NewChanInput(make(chan int)),
+		"queue": NewQueue(),
+	}
+
+	for name, provider := range providers {
+		ic, err := CreateLoad(nil, "./test_input/TstProgInputOutput", 0, 0)
+		if err != nil {
+			t.Fatalf(`TestInputCancel: failed to load program: %v`, err)
+		}
+		SetInput(ic, provider)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		result := make(chan error, 1)
+		go func() {
+			result <- RunContext(ctx, ic)
+		}()
+
+		select {
+		case err := <-result:
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf(`TestInputCancel: %v returned %v, want deadline exceeded`, name, err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf(`TestInputCancel: %v still waiting for input after deadline`, name)
+		}
+		cancel()
+
+		SetInput(ic, provider)
+		go func() {
+			result <- RunContext(context.Background(), ic)
+		}()
+		time.Sleep(10 * time.Millisecond)
+		Close(ic)
+
+		select {
+		case err := <-result:
+			if !errors.Is(err, ErrClosed) {
+				t.Fatalf(`TestInputCancel: %v returned %v after close, want ErrClosed`, name, err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf(`TestInputCancel: %v still waiting for input after close`, name)
+		}
+	}
+}
+
+func TestChanInputRun(t *testing.T) {
+	wg := new(sync.WaitGroup)
+	ic, err := CreateLoad(wg, "./test_input/TstProgInputOutput", 0, 0)
+	if err != nil {
+		t.Fatalf(`TestChanInputRun: failed to load program: %v`, err)
+	}
+	defer Close(ic)
+
+	ch := make(chan int, 1)
+	ch <- 42
+	SetInput(ic, NewChanInput(ch))
+
+	wg.Add(1)
+	go Run(ic, "")
+
+	value, sig, err := Read(ic)
+	if err != nil || sig != SigNone || value != 42 {
+		t.Fatalf(`TestChanInputRun: returned (%v, %v, %v), want (42, %v, nil)`, value, sig, err, SigNone)
+	}
+
+	if _, sig, _ := Read(ic); sig != SigHalt {
+		t.Fatalf(`TestChanInputRun: returned %v, want %v`, sig, SigHalt)
+	}
+	wg.Wait()
+}
@@ -1,18 +1,22 @@
 package intcode
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"io"
+	"math/bits"
 	"os"
+	"strings"
 	"sync"
-
-	filereader "github.com/jblashki/aoc-filereader-go"
 )
 
 //////////////////////
 // Consts and types //
 //////////////////////
 
+// minInt is the smallest value an intcode memory cell can hold
+const minInt = -1 << (bits.UintSize - 1)
+
 const opSum = 1
 const opMul = 2
 const opInp = 3
@@ -24,12 +28,29 @@ const opEqu = 8
 const opRbs = 9
 const opHlt = 99
 
-type paramMode int
+var opTable = map[int]Operation{
+	opSum: {opSum, "add", 3, 2},
+	opMul: {opMul, "mul", 3, 2},
+	opInp: {opInp, "in", 1, 0},
+	opOut: {opOut, "out", 1, -1},
+	opJpt: {opJpt, "jt", 2, -1},
+	opJpf: {opJpf, "jf", 2, -1},
+	opLst: {opLst, "lt", 3, 2},
+	opEqu: {opEqu, "eq", 3, 2},
+	opRbs: {opRbs, "arb", 1, -1},
+	opHlt: {opHlt, "hlt", 0, -1},
+}
+
+// ParamMode is the mode of an instruction parameter
+type ParamMode int
 
 const (
-	modePos = iota // Postion mode i.e. address
-	modeVal        // Value mode i.e. absolute value
-	modeRel        // Relative mode i.e. relative address
+	// ModePos is postion mode i.e. address
+	ModePos ParamMode = iota
+	// ModeVal is value mode i.e. absolute value
+	ModeVal
+	// ModeRel is relative mode i.e. relative address
+	ModeRel
 )
 
 //Signal is signal value returned by read/write methods
@@ -46,117 +67,194 @@ const (
 	SigError
 )
 
+// State is the execution state returned by Step and RunUntil
+type State int
+
+const (
+	// StateRunning means the instruction executed and the intcode can continue
+	StateRunning State = iota
+	// StateInput means the intcode program requires input which can be queued with Feed
+	StateInput
+	// StateOutput means the intcode program produced an output value
+	StateOutput
+	// StateHalted means the intcode program halted successfully
+	StateHalted
+	// StateError means the intcode program halted with an error
+	StateError
+)
+
 ////////////////////////
 // Exported functions //
 ////////////////////////
 
 // IntCode is the main intcode structure used to define an intcode computer
 type IntCode struct {
-	memory       []int
+	memory       Memory
+	program      *Program
 	programPos   int
 	relativeBase int
+	input        []int
+	steps        int
+	maxSteps     int
+	maxMemory    int
+	overflow     bool
 	inputChan    chan int
 	outputChan   chan int
 	signalChan   chan Signal
-	errorChan    chan string
+	errorChan    chan error
+	done         chan struct{}
+	closeOnce    *sync.Once
 	wg           *sync.WaitGroup
-	moribund     bool
+	tracer       Tracer
+	ctx          context.Context
+	provider     InputProvider
+	consumer     OutputConsumer
 }
 
-// Create creates a new intcode computer
+// Create creates a new intcode computer using dense memory
 func Create(wg *sync.WaitGroup, inputBufSize int, outputBufSize int) *IntCode {
+	return CreateWithMemory(wg, NewDenseMemory(make([]int, 0)), inputBufSize, outputBufSize)
+}
+
+// CreateWithMemory creates a new intcode computer using mem for storage. Programs loaded are stored in mem
+func CreateWithMemory(wg *sync.WaitGroup, mem Memory, inputBufSize int, outputBufSize int) *IntCode {
 	newIC := new(IntCode)
 
-	newIC.memory = make([]int, 0)
+	newIC.memory = mem
 	newIC.programPos = 0
 	newIC.relativeBase = 0
 
-	if inputBufSize > 0 {
-		newIC.inputChan = make(chan int, inputBufSize)
-	} else {
-		newIC.inputChan = make(chan int)
-	}
-	if outputBufSize > 0 {
-		newIC.outputChan = make(chan int, outputBufSize)
-	} else {
-		newIC.outputChan = make(chan int)
-	}
-	newIC.signalChan = make(chan Signal, 1)
-	newIC.errorChan = make(chan string, 1)
+	makeChannels(newIC, inputBufSize, outputBufSize)
 	newIC.wg = wg
-	newIC.moribund = false
 
 	return newIC
 }
 
 // CreateLoad creates a new intcode and loads program from filename
 func CreateLoad(wg *sync.WaitGroup, filename string, inputBufSize int, outputBufSize int) (*IntCode, error) {
-	returnIC := Create(wg, inputBufSize, outputBufSize)
-
-	err := Load(returnIC, filename)
+	p, err := LoadProgram(filename)
 	if err != nil {
 		return nil, err
 	}
 
-	return returnIC, nil
+	return Instantiate(p, wg, inputBufSize, outputBufSize), nil
 }
 
-// Close closes and cleans up intcode
+// Close closes and cleans up intcode. A running intcode stops at its next instruction or blocked read/write
 func Close(ic *IntCode) {
-	ic.moribund = true
-	close(ic.inputChan)
-	close(ic.outputChan)
-	close(ic.signalChan)
-	close(ic.errorChan)
+	ic.closeOnce.Do(func() {
+		close(ic.done)
+	})
 }
 
-// Copy does a deep copy of an intcode computer
+// Copy does a deep copy of an intcode computer. The source is only read, so an intcode which is not running may
+// be copied from several goroutines at once. Paged and sparse memory, used by CreateLoad and Instantiate, is shared
+// copy-on-write so copying is cheap and a page is only duplicated when one side writes to it. Input providers
+// and output consumers hold state of their own so are not copied; set them on the copy if needed. The tracer is shared
 func Copy(sourceIC *IntCode) *IntCode {
 	copiedIC := *sourceIC
 
-	copiedIC.memory = make([]int, len(sourceIC.memory))
-	copy(copiedIC.memory, sourceIC.memory)
+	copiedIC.memory = sourceIC.memory.Clone()
+
+	copiedIC.input = make([]int, len(sourceIC.input))
+	copy(copiedIC.input, sourceIC.input)
+
+	makeChannels(&copiedIC, cap(sourceIC.inputChan), cap(sourceIC.outputChan))
+	copiedIC.provider = nil
+	copiedIC.consumer = nil
+	copiedIC.ctx = nil
 
 	return &copiedIC
 }
 
 // Set sets an address in an intcode to a specific value
 func Set(ic *IntCode, addr int, value int) error {
-	if addr >= len(ic.memory) {
-		newSpace := addr - len(ic.memory) + 1
-		newMem := make([]int, newSpace)
-		ic.memory = append(ic.memory, newMem...)
+	if addr < 0 {
+		return &NegativeAddressError{Target: addr}
 	}
 
-	ic.memory[addr] = value
+	if ic.maxMemory > 0 && addr >= ic.maxMemory && addr >= ic.memory.Size() {
+		return &BudgetError{Steps: ic.steps, MaxSteps: ic.maxSteps, Memory: addr + 1, MaxMemory: ic.maxMemory}
+	}
+
+	ic.memory.Set(addr, value)
 
 	return nil
 }
 
+// SetMaxSteps limits the number of instructions an intcode may execute. 0 means no limit
+func SetMaxSteps(ic *IntCode, maxSteps int) {
+	ic.maxSteps = maxSteps
+}
+
+// SetMaxMemory limits the number of memory cells an intcode may grow to. 0 means no limit
+func SetMaxMemory(ic *IntCode, maxMemory int) {
+	ic.maxMemory = maxMemory
+}
+
+// SetOverflowCheck sets whether add and mul instructions fail with an OverflowError instead of wrapping around
+func SetOverflowCheck(ic *IntCode, check bool) {
+	ic.overflow = check
+}
+
+// SetTracer sets the tracer which receives an event for every instruction an intcode executes. nil disables tracing
+func SetTracer(ic *IntCode, tracer Tracer) {
+	ic.tracer = tracer
+}
+
+// Pos returns the program position of an intcode
+func Pos(ic *IntCode) int {
+	return ic.programPos
+}
+
+// SetPos sets the program position of an intcode
+func SetPos(ic *IntCode, pos int) {
+	ic.programPos = pos
+}
+
+// RelativeBase returns the relative base of an intcode
+func RelativeBase(ic *IntCode) int {
+	return ic.relativeBase
+}
+
+// SetRelativeBase sets the relative base of an intcode
+func SetRelativeBase(ic *IntCode, base int) {
+	ic.relativeBase = base
+}
+
+// Size returns the number of memory cells in use by an intcode
+func Size(ic *IntCode) int {
+	return ic.memory.Size()
+}
+
+// Steps returns the number of instructions an intcode has executed since it was last run
+func Steps(ic *IntCode) int {
+	return ic.steps
+}
+
 // Get returns the value at a specific address in an intocode
 func Get(ic *IntCode, addr int) int {
-	if addr >= len(ic.memory) {
+	if addr < 0 {
 		return 0
 	}
 
-	return ic.memory[addr]
+	return ic.memory.Get(addr)
 }
 
-// Run runs a specific int code
+// Run runs a specific int code. If debugFile is given a text trace is appended to it
 func Run(ic *IntCode, debugFile string) {
 	ic.programPos = 0
 	ic.relativeBase = 0
-
-	debug := false
+	ic.steps = 0
 
 	var f *os.File = nil
 	var err error = nil
 
+	tracer := ic.tracer
 	if debugFile != "" {
 		f, err = os.OpenFile(debugFile, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
 		if err == nil {
-			log.SetOutput(f)
-			debug = true
+			ic.tracer = NewTextTracer(f)
 		}
 	}
 
@@ -164,255 +262,371 @@ func Run(ic *IntCode, debugFile string) {
 		if f != nil {
 			f.Close()
 		}
-		ic.wg.Done()
+		ic.tracer = tracer
+		if ic.wg != nil {
+			ic.wg.Done()
+		}
 	}()
 
+	run(context.Background(), ic)
+}
+
+// RunContext runs an intcode like Run but stops when ctx is done, checking it between instructions and while
+// blocked on input or output. Returns the error the program halted with, ctx.Err() if cancelled or ErrClosed if closed
+func RunContext(ctx context.Context, ic *IntCode) error {
+	ic.programPos = 0
+	ic.relativeBase = 0
+	ic.steps = 0
+
+	defer func() {
+		if ic.wg != nil {
+			ic.wg.Done()
+		}
+	}()
+
+	return run(ctx, ic)
+}
+
+// Feed queues input values for the intcode. Queued values are consumed before the input provider is consulted
+func Feed(ic *IntCode, values ...int) {
+	ic.input = append(ic.input, values...)
+}
+
+// RunUntil runs an intcode from its current position until it requires input, produces output, halts or fails.
+// The output value is only valid when the returned state is StateOutput
+func RunUntil(ic *IntCode) (value int, state State, err error) {
 	for {
-		if ic.moribund {
+		value, state, err = Step(ic)
+		if state != StateRunning {
 			return
 		}
-		fullOp := readNextAddr(ic)
-		op := fullOp % 100
+	}
+}
 
-		switch op {
-		case opSum:
-			param1 := readNextAddr(ic)
-			param2 := readNextAddr(ic)
-			param3 := readNextAddr(ic)
-			param1Mode := getParamMode(fullOp, 0)
-			param2Mode := getParamMode(fullOp, 1)
-			param3Mode := getParamMode(fullOp, 2)
+// Step executes a single instruction at the current position of an intcode.
+// The output value is only valid when the returned state is StateOutput.
+// When input is required but none is queued, or the machine has halted or failed,
+// the position is left on the instruction so it can be stepped again
+func Step(ic *IntCode) (value int, state State, err error) {
+	startPos := ic.programPos
+	if _, err := validate(ic.memory, startPos); err != nil {
+		return fail(ic, err, startPos)
+	}
 
-			val1 := getParamValue(ic, param1, param1Mode)
-			val2 := getParamValue(ic, param2, param2Mode)
+	fullOp := readNextAddr(ic)
+	op := fullOp % 100
 
-			outAddr := param3
-			if param3Mode == modeRel {
-				outAddr += ic.relativeBase
-			}
+	if ic.maxSteps > 0 && ic.steps >= ic.maxSteps && op != opHlt {
+		return fail(ic, &BudgetError{Steps: ic.steps, MaxSteps: ic.maxSteps, Memory: ic.memory.Size(), MaxMemory: ic.maxMemory}, startPos)
+	}
 
-			if debug {
-				log.Printf("[%v, %v] OP_SUM (%v mode %v, %v mode %v, %v mode %v) %v + %v => 0x%v", ic.programPos, ic.relativeBase,
-					param1, param1Mode, param2, param2Mode, param3, param3Mode, val1, val2, outAddr)
-			}
+	switch op {
+	case opSum:
+		param1 := readNextAddr(ic)
+		param2 := readNextAddr(ic)
+		param3 := readNextAddr(ic)
+		param1Mode := getParamMode(fullOp, 0)
+		param2Mode := getParamMode(fullOp, 1)
+		param3Mode := getParamMode(fullOp, 2)
+
+		val1, err := getParamValue(ic, param1, param1Mode)
+		if err != nil {
+			return fail(ic, err, startPos)
+		}
+		val2, err := getParamValue(ic, param2, param2Mode)
+		if err != nil {
+			return fail(ic, err, startPos)
+		}
 
-			err := Set(ic, outAddr, val1+val2)
-			if err != nil {
-				ic.signalChan <- SigError
-				errorMsg := fmt.Sprintf("Error setting address %v @ address %v: %v", param3, ic.programPos-4, err)
-				ic.errorChan <- errorMsg
-				return
-			}
+		outAddr := param3
+		if param3Mode == ModeRel {
+			outAddr += ic.relativeBase
+		}
 
-		case opMul:
-			param1 := readNextAddr(ic)
-			param2 := readNextAddr(ic)
-			param3 := readNextAddr(ic)
-			param1Mode := getParamMode(fullOp, 0)
-			param2Mode := getParamMode(fullOp, 1)
-			param3Mode := getParamMode(fullOp, 2)
+		result, err := add(ic, val1, val2)
+		if err != nil {
+			return fail(ic, err, startPos)
+		}
 
-			val1 := getParamValue(ic, param1, param1Mode)
-			val2 := getParamValue(ic, param2, param2Mode)
+		if ic.tracer != nil {
+			trace(ic, startPos, fullOp, []int{param1, param2, param3}, []int{val1, val2, result}, outAddr)
+		}
 
-			outAddr := param3
-			if param3Mode == modeRel {
-				outAddr += ic.relativeBase
-			}
+		if err := Set(ic, outAddr, result); err != nil {
+			return fail(ic, err, startPos)
+		}
 
-			if debug {
-				log.Printf("[%v, %v] OP_MUL (%v mode %v, %v mode %v, %v mode %v) %v * %v => 0x%v", ic.programPos-4, ic.relativeBase,
-					param1, param1Mode, param2, param2Mode, param3, param3Mode, val1, val2, outAddr)
-			}
+	case opMul:
+		param1 := readNextAddr(ic)
+		param2 := readNextAddr(ic)
+		param3 := readNextAddr(ic)
+		param1Mode := getParamMode(fullOp, 0)
+		param2Mode := getParamMode(fullOp, 1)
+		param3Mode := getParamMode(fullOp, 2)
+
+		val1, err := getParamValue(ic, param1, param1Mode)
+		if err != nil {
+			return fail(ic, err, startPos)
+		}
+		val2, err := getParamValue(ic, param2, param2Mode)
+		if err != nil {
+			return fail(ic, err, startPos)
+		}
 
-			err := Set(ic, outAddr, val1*val2)
-			if err != nil {
-				ic.signalChan <- SigError
-				errorMsg := fmt.Sprintf("Error setting address %v @ address %v: %v", param3, ic.programPos-4, err)
-				ic.errorChan <- errorMsg
-				return
-			}
+		outAddr := param3
+		if param3Mode == ModeRel {
+			outAddr += ic.relativeBase
+		}
 
-		case opInp:
-			param1 := readNextAddr(ic)
-			param1Mode := getParamMode(fullOp, 0)
+		result, err := mul(ic, val1, val2)
+		if err != nil {
+			return fail(ic, err, startPos)
+		}
 
-			outAddr := param1
-			if param1Mode == modeRel {
-				outAddr += ic.relativeBase
-			}
-			// Signal That input is required
-			ic.signalChan <- SigInput
+		if ic.tracer != nil {
+			trace(ic, startPos, fullOp, []int{param1, param2, param3}, []int{val1, val2, result}, outAddr)
+		}
 
-			// Get Input
-			val := <-ic.inputChan
+		if err := Set(ic, outAddr, result); err != nil {
+			return fail(ic, err, startPos)
+		}
 
-			if debug {
-				log.Printf("[%v, %v] OP_INP (%v mode %v) %v => 0x%v", ic.programPos-2, ic.relativeBase,
-					param1, param1Mode, val, outAddr)
-			}
+	case opInp:
+		// Get Input
+		val, ok, err := nextInput(ic)
+		if err != nil {
+			return fail(ic, err, startPos)
+		}
+		if !ok {
+			ic.programPos = startPos
+			return 0, StateInput, nil
+		}
 
-			err := Set(ic, outAddr, val)
-			if err != nil {
-				ic.signalChan <- SigError
-				errorMsg := fmt.Sprintf("Error setting address %v @ address %v: %v", param1, ic.programPos-2, err)
-				ic.errorChan <- errorMsg
-				return
-			}
+		param1 := readNextAddr(ic)
+		param1Mode := getParamMode(fullOp, 0)
 
-		case opOut:
-			param1 := readNextAddr(ic)
-			param1Mode := getParamMode(fullOp, 0)
+		outAddr := param1
+		if param1Mode == ModeRel {
+			outAddr += ic.relativeBase
+		}
 
-			val1 := getParamValue(ic, param1, param1Mode)
+		if ic.tracer != nil {
+			trace(ic, startPos, fullOp, []int{param1}, []int{val}, outAddr)
+		}
 
-			if debug {
-				log.Printf("[%v, %v] OP_OUT (%v mode %v) %v => output", ic.programPos-2, ic.relativeBase,
-					param1, param1Mode, val1)
-			}
+		if err := Set(ic, outAddr, val); err != nil {
+			return fail(ic, err, startPos)
+		}
 
-			ic.outputChan <- val1
+	case opOut:
+		param1 := readNextAddr(ic)
+		param1Mode := getParamMode(fullOp, 0)
 
-		case opJpt:
-			param1 := readNextAddr(ic)
-			param2 := readNextAddr(ic)
-			param1Mode := getParamMode(fullOp, 0)
-			param2Mode := getParamMode(fullOp, 1)
+		val1, err := getParamValue(ic, param1, param1Mode)
+		if err != nil {
+			return fail(ic, err, startPos)
+		}
 
-			val1 := getParamValue(ic, param1, param1Mode)
-			val2 := getParamValue(ic, param2, param2Mode)
+		if ic.tracer != nil {
+			trace(ic, startPos, fullOp, []int{param1}, []int{val1}, -1)
+		}
 
-			if debug {
-				log.Printf("[%v, %v] OP_JPT (%v mode %v, %v mode %v) jump to 0x%v if %v != 0", ic.programPos-3, ic.relativeBase,
-					param1, param1Mode, param2, param2Mode, val2, val1)
+		if ic.consumer != nil {
+			if err := output(ic, val1); err != nil {
+				return fail(ic, err, startPos)
 			}
+			break
+		}
 
-			if val1 != 0 {
-				ic.programPos = val2
-			}
+		ic.steps++
 
-		case opJpf:
-			param1 := readNextAddr(ic)
-			param2 := readNextAddr(ic)
-			param1Mode := getParamMode(fullOp, 0)
-			param2Mode := getParamMode(fullOp, 1)
+		return val1, StateOutput, nil
 
-			val1 := getParamValue(ic, param1, param1Mode)
-			val2 := getParamValue(ic, param2, param2Mode)
+	case opJpt:
+		param1 := readNextAddr(ic)
+		param2 := readNextAddr(ic)
+		param1Mode := getParamMode(fullOp, 0)
+		param2Mode := getParamMode(fullOp, 1)
 
-			if debug {
-				log.Printf("[%v, %v] OP_JPF (%v mode %v, %v mode %v) jump to 0x%v if %v == 0", ic.programPos-3, ic.relativeBase,
-					param1, param1Mode, param2, param2Mode, val2, val1)
-			}
+		val1, err := getParamValue(ic, param1, param1Mode)
+		if err != nil {
+			return fail(ic, err, startPos)
+		}
+		val2, err := getParamValue(ic, param2, param2Mode)
+		if err != nil {
+			return fail(ic, err, startPos)
+		}
 
-			if val1 == 0 {
-				ic.programPos = val2
-			}
+		if ic.tracer != nil {
+			trace(ic, startPos, fullOp, []int{param1, param2}, []int{val1, val2}, -1)
+		}
 
-		case opLst:
-			param1 := readNextAddr(ic)
-			param2 := readNextAddr(ic)
-			param3 := readNextAddr(ic)
-			param1Mode := getParamMode(fullOp, 0)
-			param2Mode := getParamMode(fullOp, 1)
-			param3Mode := getParamMode(fullOp, 2)
+		if val1 != 0 {
+			ic.programPos = val2
+		}
 
-			val1 := getParamValue(ic, param1, param1Mode)
-			val2 := getParamValue(ic, param2, param2Mode)
+	case opJpf:
+		param1 := readNextAddr(ic)
+		param2 := readNextAddr(ic)
+		param1Mode := getParamMode(fullOp, 0)
+		param2Mode := getParamMode(fullOp, 1)
 
-			outAddr := param3
-			if param3Mode == modeRel {
-				outAddr += ic.relativeBase
-			}
+		val1, err := getParamValue(ic, param1, param1Mode)
+		if err != nil {
+			return fail(ic, err, startPos)
+		}
+		val2, err := getParamValue(ic, param2, param2Mode)
+		if err != nil {
+			return fail(ic, err, startPos)
+		}
 
-			outValue := 0
-			if val1 < val2 {
-				outValue = 1
-			}
+		if ic.tracer != nil {
+			trace(ic, startPos, fullOp, []int{param1, param2}, []int{val1, val2}, -1)
+		}
 
-			if debug {
-				log.Printf("[%v, %v] OP_LST (%v mode %v, %v mode %v, %v mode %v) input %v into 0x%v", ic.programPos-4, ic.relativeBase,
-					param1, param1Mode, param2, param2Mode, param3, param3Mode, outValue, outAddr)
-			}
+		if val1 == 0 {
+			ic.programPos = val2
+		}
 
-			err := Set(ic, outAddr, outValue)
-			if err != nil {
-				ic.signalChan <- SigError
-				errorMsg := fmt.Sprintf("Error setting address %v @ address %v: %v", param3, ic.programPos-4, err)
-				ic.errorChan <- errorMsg
-				return
-			}
+	case opLst:
+		param1 := readNextAddr(ic)
+		param2 := readNextAddr(ic)
+		param3 := readNextAddr(ic)
+		param1Mode := getParamMode(fullOp, 0)
+		param2Mode := getParamMode(fullOp, 1)
+		param3Mode := getParamMode(fullOp, 2)
+
+		val1, err := getParamValue(ic, param1, param1Mode)
+		if err != nil {
+			return fail(ic, err, startPos)
+		}
+		val2, err := getParamValue(ic, param2, param2Mode)
+		if err != nil {
+			return fail(ic, err, startPos)
+		}
 
-		case opEqu:
-			param1 := readNextAddr(ic)
-			param2 := readNextAddr(ic)
-			param3 := readNextAddr(ic)
-			param1Mode := getParamMode(fullOp, 0)
-			param2Mode := getParamMode(fullOp, 1)
-			param3Mode := getParamMode(fullOp, 2)
+		outAddr := param3
+		if param3Mode == ModeRel {
+			outAddr += ic.relativeBase
+		}
 
-			val1 := getParamValue(ic, param1, param1Mode)
-			val2 := getParamValue(ic, param2, param2Mode)
+		outValue := 0
+		if val1 < val2 {
+			outValue = 1
+		}
 
-			outAddr := param3
-			if param3Mode == modeRel {
-				outAddr += ic.relativeBase
-			}
+		if ic.tracer != nil {
+			trace(ic, startPos, fullOp, []int{param1, param2, param3}, []int{val1, val2, outValue}, outAddr)
+		}
 
-			outValue := 0
-			if val1 == val2 {
-				outValue = 1
-			}
+		if err := Set(ic, outAddr, outValue); err != nil {
+			return fail(ic, err, startPos)
+		}
 
-			if debug {
-				log.Printf("[%v, %v] OP_EQU (%v mode %v, %v mode %v, %v mode %v) input %v into 0x%v", ic.programPos-4, ic.relativeBase,
-					param1, param1Mode, param2, param2Mode, param3, param3Mode, outValue, outAddr)
-			}
+	case opEqu:
+		param1 := readNextAddr(ic)
+		param2 := readNextAddr(ic)
+		param3 := readNextAddr(ic)
+		param1Mode := getParamMode(fullOp, 0)
+		param2Mode := getParamMode(fullOp, 1)
+		param3Mode := getParamMode(fullOp, 2)
+
+		val1, err := getParamValue(ic, param1, param1Mode)
+		if err != nil {
+			return fail(ic, err, startPos)
+		}
+		val2, err := getParamValue(ic, param2, param2Mode)
+		if err != nil {
+			return fail(ic, err, startPos)
+		}
 
-			err := Set(ic, outAddr, outValue)
-			if err != nil {
-				ic.signalChan <- SigError
-				errorMsg := fmt.Sprintf("Error setting address %v @ address %v: %v", param3, ic.programPos-4, err)
-				ic.errorChan <- errorMsg
-				return
-			}
+		outAddr := param3
+		if param3Mode == ModeRel {
+			outAddr += ic.relativeBase
+		}
 
-		case opHlt:
-			ic.signalChan <- SigHalt
-			if debug {
-				log.Printf("[%v, %v] OP_HLT", ic.programPos-1, ic.relativeBase)
-			}
-			return
+		outValue := 0
+		if val1 == val2 {
+			outValue = 1
+		}
 
-		case opRbs:
-			param1 := readNextAddr(ic)
-			param1Mode := getParamMode(fullOp, 0)
+		if ic.tracer != nil {
+			trace(ic, startPos, fullOp, []int{param1, param2, param3}, []int{val1, val2, outValue}, outAddr)
+		}
 
-			val1 := getParamValue(ic, param1, param1Mode)
+		if err := Set(ic, outAddr, outValue); err != nil {
+			return fail(ic, err, startPos)
+		}
 
-			if debug {
-				log.Printf("[%v, %v] OP_RBS (%v mode %v) %v => relativeBase", ic.programPos-2, ic.relativeBase,
-					param1, param1Mode, val1)
-			}
+	case opHlt:
+		ic.programPos = startPos
+		if ic.tracer != nil {
+			trace(ic, startPos, fullOp, nil, nil, -1)
+		}
+		return 0, StateHalted, nil
 
-			ic.relativeBase += val1
+	case opRbs:
+		param1 := readNextAddr(ic)
+		param1Mode := getParamMode(fullOp, 0)
 
-		default:
-			ic.signalChan <- SigError
-			errorMsg := fmt.Sprintf("Unknown operation %v at address %v", op, ic.programPos-1)
-			ic.errorChan <- errorMsg
-			return
+		val1, err := getParamValue(ic, param1, param1Mode)
+		if err != nil {
+			return fail(ic, err, startPos)
 		}
+
+		if ic.tracer != nil {
+			trace(ic, startPos, fullOp, []int{param1}, []int{val1}, -1)
+		}
+
+		ic.relativeBase += val1
 	}
+
+	ic.steps++
+
+	return 0, StateRunning, nil
 }
 
 // Load loads an intcode with data from the file specificed
 func Load(ic *IntCode, file string) error {
-	var err error
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
 
-	ic.memory, err = filereader.ReadCSVInts(file)
+	if err := LoadReader(ic, f); err != nil {
+		return fmt.Errorf("%v: %w", file, err)
+	}
 
-	return err
+	return nil
+}
+
+// LoadReader loads an intcode with a program read from r. Values are separated by commas or whitespace
+// and # starts a comment to the end of the line. A malformed value is reported as a ParseError
+func LoadReader(ic *IntCode, r io.Reader) error {
+	values, err := parseProgram(r)
+	if err != nil {
+		return err
+	}
+
+	ic.memory.Replace(values)
+	ic.program = &Program{ic.memory.Clone()}
+
+	return nil
+}
+
+// LoadString loads an intcode with a program in the same format as LoadReader
+func LoadString(ic *IntCode, program string) error {
+	return LoadReader(ic, strings.NewReader(program))
+}
+
+// LoadInts loads an intcode with a copy of values
+func LoadInts(ic *IntCode, values []int) {
+	program := make([]int, len(values))
+	copy(program, values)
+
+	ic.memory.Replace(program)
+	ic.program = &Program{ic.memory.Clone()}
 }
 
 // Read reads value from intcode output. Will value or signal recieved and error if present
@@ -425,8 +639,12 @@ func Read(ic *IntCode) (value int, sig Signal, err error) {
 	case sig = <-ic.signalChan:
 		if sig == SigError {
 			errOutput := <-ic.errorChan
-			err = fmt.Errorf("Program error: %v", errOutput)
+			err = fmt.Errorf("Program error: %w", errOutput)
 		}
+
+	case <-ic.done:
+		sig = SigError
+		err = ErrClosed
 	}
 
 	return
@@ -440,7 +658,10 @@ func Write(ic *IntCode, input int) {
 	// 	errOutput := <-ic.errorChan
 	// 	err = fmt.Errorf("Program error: %v", errOutput)
 	// } else if sig == SigInput {
-	ic.inputChan <- input
+	select {
+	case ic.inputChan <- input:
+	case <-ic.done:
+	}
 	// 	sig = SigNone
 	// 	err = nil
 	// }
@@ -468,15 +689,165 @@ func Write(ic *IntCode, input int) {
 // Unexported functions //
 //////////////////////////
 
+func run(ctx context.Context, ic *IntCode) error {
+	ic.ctx = ctx
+	defer func() {
+		ic.ctx = nil
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return abort(ic, ctx.Err())
+		case <-ic.done:
+			return ErrClosed
+		default:
+		}
+
+		value, state, err := Step(ic)
+
+		switch state {
+		case StateInput:
+			if waiter, ok := ic.provider.(inputWaiter); ok {
+				// Wait for the provider, the next step reports it closing
+				if val, ok := waiter.waitInput(ctx, ic.done); ok {
+					Feed(ic, val)
+				}
+				continue
+			}
+
+			// Signal That input is required
+			select {
+			case ic.signalChan <- SigInput:
+			case <-ctx.Done():
+				return abort(ic, ctx.Err())
+			case <-ic.done:
+				return ErrClosed
+			}
+
+			// Get Input
+			select {
+			case val := <-ic.inputChan:
+				Feed(ic, val)
+			case <-ctx.Done():
+				return abort(ic, ctx.Err())
+			case <-ic.done:
+				return newFault(ic, &InputClosedError{}, ic.programPos)
+			}
+
+		case StateOutput:
+			select {
+			case ic.outputChan <- value:
+			case <-ctx.Done():
+				return abort(ic, ctx.Err())
+			case <-ic.done:
+				return ErrClosed
+			}
+
+		case StateHalted:
+			select {
+			case ic.signalChan <- SigHalt:
+			case <-ctx.Done():
+			case <-ic.done:
+			}
+			return nil
+
+		case StateError:
+			select {
+			case ic.signalChan <- SigError:
+				ic.errorChan <- err
+			case <-ctx.Done():
+			case <-ic.done:
+			}
+			return err
+		}
+	}
+}
+
+// output passes a value to the output consumer, waiting with the context of the current run if it blocks
+func output(ic *IntCode, value int) error {
+	waiter, ok := ic.consumer.(outputWaiter)
+	if !ok {
+		return ic.consumer.Output(value)
+	}
+
+	ctx := ic.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return waiter.waitOutput(ctx, ic.done, value)
+}
+
+// abort reports err to a reader if the signal channel has room and returns it
+func abort(ic *IntCode, err error) error {
+	select {
+	case ic.signalChan <- SigError:
+		ic.errorChan <- err
+	default:
+	}
+
+	return err
+}
+
+// drainChannels discards values buffered on the channels of an intcode
+func drainChannels(ic *IntCode) {
+	for {
+		select {
+		case <-ic.inputChan:
+		case <-ic.outputChan:
+		case <-ic.signalChan:
+		case <-ic.errorChan:
+		default:
+			return
+		}
+	}
+}
+
+func makeChannels(ic *IntCode, inputBufSize int, outputBufSize int) {
+	if inputBufSize > 0 {
+		ic.inputChan = make(chan int, inputBufSize)
+	} else {
+		ic.inputChan = make(chan int)
+	}
+	if outputBufSize > 0 {
+		ic.outputChan = make(chan int, outputBufSize)
+	} else {
+		ic.outputChan = make(chan int)
+	}
+	ic.signalChan = make(chan Signal, 1)
+	ic.errorChan = make(chan error, 1)
+	ic.done = make(chan struct{})
+	ic.closeOnce = new(sync.Once)
+}
+
+func add(ic *IntCode, a int, b int) (int, error) {
+	sum := a + b
+	if ic.overflow && (sum > a) != (b > 0) {
+		return 0, &OverflowError{Name: opTable[opSum].Name, A: a, B: b}
+	}
+
+	return sum, nil
+}
+
+func mul(ic *IntCode, a int, b int) (int, error) {
+	product := a * b
+	if ic.overflow && a != 0 && (product/a != b || (a == -1 && b == minInt) || (b == -1 && a == minInt)) {
+		return 0, &OverflowError{Name: opTable[opMul].Name, A: a, B: b}
+	}
+
+	return product, nil
+}
+
 func readNextAddr(ic *IntCode) int {
-	value := ic.memory[ic.programPos]
+	value := ic.memory.Get(ic.programPos)
 
 	(ic.programPos)++
 
 	return value
 }
 
-func getParamMode(op int, param int) paramMode {
+func getParamMode(op int, param int) ParamMode {
 	op /= 100
 
 	for param > 0 {
@@ -484,23 +855,53 @@ func getParamMode(op int, param int) paramMode {
 		param--
 	}
 
-	mode := op % 10
-	if mode == 0 {
-		return modePos
-	} else if mode == 1 {
-		return modeVal
-	} else {
-		return modeRel
+	return ParamMode(op % 10)
+}
+
+// newFault fills in the fault details of err for the instruction at addr
+func newFault(ic *IntCode, err error, addr int) error {
+	f, ok := err.(faulter)
+	if !ok {
+		return err
+	}
+
+	fullOp := Get(ic, addr)
+	fault := Fault{
+		Addr:         addr,
+		Opcode:       fullOp,
+		RelativeBase: ic.relativeBase,
 	}
+
+	if info, known := opTable[fullOp%100]; known {
+		fault.Modes = make([]ParamMode, info.Params)
+		for i := range fault.Modes {
+			fault.Modes[i] = getParamMode(fullOp, i)
+		}
+	}
+
+	f.setFault(fault)
+
+	return err
 }
 
-func getParamValue(ic *IntCode, param int, mode paramMode) int {
-	returnVal := param
-	if mode == modePos {
-		returnVal = Get(ic, param)
-	} else if mode == modeRel {
-		returnVal = Get(ic, ic.relativeBase+param)
+func getParamValue(ic *IntCode, param int, mode ParamMode) (int, error) {
+	addr := param
+	if mode == ModeVal {
+		return param, nil
+	} else if mode == ModeRel {
+		addr += ic.relativeBase
+	}
+
+	if addr < 0 {
+		return 0, &NegativeAddressError{Target: addr}
 	}
 
-	return returnVal
+	return Get(ic, addr), nil
+}
+
+// fail leaves the program position on the failing instruction at addr and returns err with its fault details
+func fail(ic *IntCode, err error, addr int) (int, State, error) {
+	ic.programPos = addr
+
+	return 0, StateError, newFault(ic, err, addr)
 }
@@ -1,10 +1,12 @@
 package intcode
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestProgram1(t *testing.T) {
@@ -263,3 +265,198 @@ func testInputOutputProgram(progFile string, input []int, wantOutput []int) erro
 
 	return nil
 }
+
+func TestStepProgram3(t *testing.T) {
+	wg := new(sync.WaitGroup)
+	ic, err := CreateLoad(wg, "./test_input/TstProg3", 0, 0)
+	if err != nil {
+		t.Fatalf(`TestStepProgram3: failed to load program: %v`, err)
+	}
+
+	steps := 0
+	for {
+		_, state, err := Step(ic)
+		if err != nil {
+			t.Fatalf(`TestStepProgram3: returned error: %v`, err)
+		} else if state == StateHalted {
+			break
+		} else if state != StateRunning {
+			t.Fatalf(`TestStepProgram3: returned unexpected state %v`, state)
+		}
+		steps++
+	}
+
+	if steps != 1 {
+		t.Fatalf(`TestStepProgram3: executed %v instructions, want 1`, steps)
+	}
+
+	if value := Get(ic, 5); value != 9801 {
+		t.Fatalf(`TestStepProgram3: returned %v, want 9801`, value)
+	}
+
+	// A halted machine stays halted
+	if _, state, _ := Step(ic); state != StateHalted {
+		t.Fatalf(`TestStepProgram3: returned %v after halt, want %v`, state, StateHalted)
+	}
+}
+
+func TestRunUntilInputOutput(t *testing.T) {
+	err := testRunUntilProgram("./test_input/TstProgInputOutput2", []int{5, 3, 10, 4}, []int{8, 40})
+	if err != nil {
+		t.Fatalf(`TestRunUntilInputOutput: returned error: %v`, err)
+	}
+}
+
+func TestRunUntilFeedAhead(t *testing.T) {
+	wg := new(sync.WaitGroup)
+	ic, err := CreateLoad(wg, "./test_input/TstProgInputOutput2", 0, 0)
+	if err != nil {
+		t.Fatalf(`TestRunUntilFeedAhead: failed to load program: %v`, err)
+	}
+
+	Feed(ic, 5, 3, 10, 4)
+
+	value, state, err := RunUntil(ic)
+	if err != nil || state != StateOutput || value != 8 {
+		t.Fatalf(`TestRunUntilFeedAhead: returned (%v, %v, %v), want (8, %v, nil)`, value, state, err, StateOutput)
+	}
+	value, state, err = RunUntil(ic)
+	if err != nil || state != StateOutput || value != 40 {
+		t.Fatalf(`TestRunUntilFeedAhead: returned (%v, %v, %v), want (40, %v, nil)`, value, state, err, StateOutput)
+	}
+	_, state, err = RunUntil(ic)
+	if err != nil || state != StateHalted {
+		t.Fatalf(`TestRunUntilFeedAhead: returned (%v, %v), want (%v, nil)`, state, err, StateHalted)
+	}
+}
+
+func testRunUntilProgram(progFile string, input []int, wantOutput []int) error {
+	wg := new(sync.WaitGroup)
+	ic, err := CreateLoad(wg, progFile, 0, 0)
+	if err != nil {
+		return fmt.Errorf("Failed to load program: %v", err)
+	}
+
+	i := 0
+	j := 0
+	for {
+		value, state, err := RunUntil(ic)
+		if err != nil {
+			return err
+		} else if state == StateHalted {
+			if i != len(wantOutput) {
+				return fmt.Errorf("Recieved halt when expecting result @ %v in test %v", i, progFile)
+			}
+			break
+		} else if state == StateInput {
+			if j >= len(input) {
+				return fmt.Errorf("Program requested unexpected input @ %v", j)
+			}
+			Feed(ic, input[j])
+			j++
+		} else {
+			if i >= len(wantOutput) {
+				return fmt.Errorf("Program returned unexpected output %v @ %v", value, i)
+			} else if value != wantOutput[i] {
+				return fmt.Errorf("Program returned %v @ %v, want %v", value, i, wantOutput[i])
+			}
+			i++
+		}
+	}
+
+	return nil
+}
+
+func TestRunContextTimeout(t *testing.T) {
+	wg := new(sync.WaitGroup)
+	ic, err := CreateLoad(wg, "./test_input/TstProgLoop", 0, 0)
+	if err != nil {
+		t.Fatalf(`TestRunContextTimeout: failed to load program: %v`, err)
+	}
+	defer Close(ic)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	wg.Add(1)
+	err = RunContext(ctx, ic)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf(`TestRunContextTimeout: returned %v, want %v`, err, context.DeadlineExceeded)
+	}
+
+	_, sig, err := Read(ic)
+	if sig != SigError || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf(`TestRunContextTimeout: Read returned (%v, %v), want (%v, %v)`, sig, err, SigError, context.DeadlineExceeded)
+	}
+}
+
+func TestRunContextCancelInput(t *testing.T) {
+	wg := new(sync.WaitGroup)
+	ic, err := CreateLoad(wg, "./test_input/TstProgInputOutput", 0, 0)
+	if err != nil {
+		t.Fatalf(`TestRunContextCancelInput: failed to load program: %v`, err)
+	}
+	defer Close(ic)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errChan := make(chan error, 1)
+
+	wg.Add(1)
+	go func() {
+		errChan <- RunContext(ctx, ic)
+	}()
+
+	_, sig, err := Read(ic)
+	if err != nil || sig != SigInput {
+		t.Fatalf(`TestRunContextCancelInput: Read returned (%v, %v), want (%v, nil)`, sig, err, SigInput)
+	}
+
+	cancel()
+	wg.Wait()
+
+	if err := <-errChan; !errors.Is(err, context.Canceled) {
+		t.Fatalf(`TestRunContextCancelInput: returned %v, want %v`, err, context.Canceled)
+	}
+}
+
+func TestCloseBlockedOutput(t *testing.T) {
+	wg := new(sync.WaitGroup)
+	ic, err := CreateLoad(wg, "./test_input/TstProgInputOutput", 0, 0)
+	if err != nil {
+		t.Fatalf(`TestCloseBlockedOutput: failed to load program: %v`, err)
+	}
+
+	Feed(ic, 10)
+
+	errChan := make(chan error, 1)
+	wg.Add(1)
+	go func() {
+		errChan <- RunContext(context.Background(), ic)
+	}()
+
+	// Nobody reads the output so the intcode blocks until it is closed
+	Close(ic)
+	wg.Wait()
+
+	if err := <-errChan; !errors.Is(err, ErrClosed) {
+		t.Fatalf(`TestCloseBlockedOutput: returned %v, want %v`, err, ErrClosed)
+	}
+
+	if _, _, err := Read(ic); !errors.Is(err, ErrClosed) {
+		t.Fatalf(`TestCloseBlockedOutput: Read returned %v, want %v`, err, ErrClosed)
+	}
+}
+
+func TestStepAllocs(t *testing.T) {
+	ic, err := CreateLoad(nil, "./test_input/TstProgLoop", 0, 0)
+	if err != nil {
+		t.Fatalf(`TestStepAllocs: failed to load program: %v`, err)
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		Step(ic)
+	})
+	if allocs != 0 {
+		t.Fatalf(`TestStepAllocs: step allocated %v times, want 0`, allocs)
+	}
+}
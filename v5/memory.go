@@ -0,0 +1,302 @@
+package intcode
+
+import (
+	"sort"
+	"sync/atomic"
+)
+
+// pageBits is the number of address bits within a page of paged memory
+const pageBits = 10
+
+// pageSize is the number of cells in a page of paged memory
+const pageSize = 1 << pageBits
+
+// Memory is the storage behind an intcode computer. Addresses passed in are never negative
+type Memory interface {
+	// Get returns the value at addr, 0 if beyond the end of memory
+	Get(addr int) int
+	// Set sets the value at addr, growing memory as needed
+	Set(addr int, value int)
+	// Size returns the number of cells in use, one past the highest address set
+	Size() int
+	// Clone returns an independent copy
+	Clone() Memory
+	// Cells returns a copy of the contents
+	Cells() []int
+	// Range calls visit with each run of stored cells in address order, stopping if visit returns false.
+	// Cells outside of the runs are 0. values must not be modified and are only valid until memory is next written
+	Range(visit func(addr int, values []int) bool)
+	// Replace replaces the contents with values
+	Replace(values []int)
+}
+
+// denseMemory stores cells in a single slice
+type denseMemory struct {
+	values []int
+}
+
+// page is a block of cells in paged or sparse memory. Once shared by a clone a page is never written again;
+// writers copy it first
+type page struct {
+	cells  [pageSize]int
+	shared int32
+}
+
+// pagedMemory stores cells in fixed size pages which are shared copy-on-write between clones
+type pagedMemory struct {
+	pages  []*page
+	length int
+}
+
+// sparseMemory stores cells in fixed size pages looked up by page number, so only pages
+// touched are allocated. Pages are shared copy-on-write between clones like pagedMemory
+type sparseMemory struct {
+	pages  map[int]*page
+	length int
+}
+
+// NewDenseMemory creates memory backed by a single slice holding values. This is the default
+// and fastest for programs using a compact range of addresses
+func NewDenseMemory(values []int) Memory {
+	return &denseMemory{values}
+}
+
+// NewPagedMemory creates memory backed by a table of pages holding a copy of values. Pages are shared
+// copy-on-write by clones, so Copy is cheap and only pages written to afterwards are duplicated
+func NewPagedMemory(values []int) Memory {
+	m := new(pagedMemory)
+	m.Replace(values)
+
+	return m
+}
+
+// NewSparseMemory creates memory backed by a map of pages holding a copy of values. Only pages
+// written to are allocated, so programs can use very large addresses
+func NewSparseMemory(values []int) Memory {
+	m := new(sparseMemory)
+	m.Replace(values)
+
+	return m
+}
+
+func (m *denseMemory) Get(addr int) int {
+	if addr >= len(m.values) {
+		return 0
+	}
+
+	return m.values[addr]
+}
+
+func (m *denseMemory) Set(addr int, value int) {
+	if addr >= len(m.values) {
+		newSpace := addr - len(m.values) + 1
+		newMem := make([]int, newSpace)
+		m.values = append(m.values, newMem...)
+	}
+
+	m.values[addr] = value
+}
+
+func (m *denseMemory) Size() int {
+	return len(m.values)
+}
+
+func (m *denseMemory) Clone() Memory {
+	return NewDenseMemory(m.Cells())
+}
+
+func (m *denseMemory) Cells() []int {
+	values := make([]int, len(m.values))
+	copy(values, m.values)
+
+	return values
+}
+
+func (m *denseMemory) Range(visit func(addr int, values []int) bool) {
+	if len(m.values) > 0 {
+		visit(0, m.values)
+	}
+}
+
+func (m *denseMemory) Replace(values []int) {
+	m.values = values
+}
+
+func (m *pagedMemory) Get(addr int) int {
+	if addr >= m.length {
+		return 0
+	}
+
+	p := m.pages[addr>>pageBits]
+	if p == nil {
+		return 0
+	}
+
+	return p.cells[addr&(pageSize-1)]
+}
+
+func (m *pagedMemory) Set(addr int, value int) {
+	index := addr >> pageBits
+	if index >= len(m.pages) {
+		m.pages = append(m.pages, make([]*page, index-len(m.pages)+1)...)
+	}
+
+	m.pages[index] = writable(m.pages[index])
+	m.pages[index].cells[addr&(pageSize-1)] = value
+
+	if addr >= m.length {
+		m.length = addr + 1
+	}
+}
+
+func (m *pagedMemory) Size() int {
+	return m.length
+}
+
+// Clone shares every page with the copy. Pages are only marked shared, atomically, so memory which is not
+// being written to may be cloned concurrently
+func (m *pagedMemory) Clone() Memory {
+	c := &pagedMemory{
+		pages:  make([]*page, len(m.pages)),
+		length: m.length,
+	}
+	copy(c.pages, m.pages)
+
+	for _, p := range m.pages {
+		share(p)
+	}
+
+	return c
+}
+
+func (m *pagedMemory) Cells() []int {
+	values := make([]int, m.length)
+	for i, p := range m.pages {
+		if p != nil {
+			copy(values[i<<pageBits:], p.cells[:])
+		}
+	}
+
+	return values
+}
+
+func (m *pagedMemory) Range(visit func(addr int, values []int) bool) {
+	for i, p := range m.pages {
+		if p != nil && !visitPage(i, p, m.length, visit) {
+			return
+		}
+	}
+}
+
+func (m *pagedMemory) Replace(values []int) {
+	m.pages = nil
+	m.length = 0
+
+	for addr := len(values) - 1; addr >= 0; addr-- {
+		m.Set(addr, values[addr])
+	}
+}
+
+func (m *sparseMemory) Get(addr int) int {
+	p, ok := m.pages[addr>>pageBits]
+	if !ok {
+		return 0
+	}
+
+	return p.cells[addr&(pageSize-1)]
+}
+
+func (m *sparseMemory) Set(addr int, value int) {
+	index := addr >> pageBits
+
+	p := writable(m.pages[index])
+	m.pages[index] = p
+	p.cells[addr&(pageSize-1)] = value
+
+	if addr >= m.length {
+		m.length = addr + 1
+	}
+}
+
+func (m *sparseMemory) Size() int {
+	return m.length
+}
+
+// Clone shares every page with the copy like pagedMemory.Clone
+func (m *sparseMemory) Clone() Memory {
+	c := &sparseMemory{
+		pages:  make(map[int]*page, len(m.pages)),
+		length: m.length,
+	}
+
+	for index, p := range m.pages {
+		c.pages[index] = p
+		share(p)
+	}
+
+	return c
+}
+
+// Cells returns a copy of the contents. This allocates every cell up to Size, so Range should be
+// used instead on memory using very large addresses
+func (m *sparseMemory) Cells() []int {
+	values := make([]int, m.length)
+	for index, p := range m.pages {
+		copy(values[index<<pageBits:], p.cells[:])
+	}
+
+	return values
+}
+
+func (m *sparseMemory) Range(visit func(addr int, values []int) bool) {
+	indexes := make([]int, 0, len(m.pages))
+	for index := range m.pages {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	for _, index := range indexes {
+		if !visitPage(index, m.pages[index], m.length, visit) {
+			return
+		}
+	}
+}
+
+func (m *sparseMemory) Replace(values []int) {
+	m.pages = make(map[int]*page)
+	m.length = 0
+
+	for addr, value := range values {
+		m.Set(addr, value)
+	}
+}
+
+// writable returns p if it may be written in place, otherwise a private copy of it or a new page if p is nil
+func writable(p *page) *page {
+	if p == nil {
+		return new(page)
+	}
+	if atomic.LoadInt32(&p.shared) == 0 {
+		return p
+	}
+
+	return &page{cells: p.cells}
+}
+
+// share marks a page as shared so neither side of a clone writes to it in place
+func share(p *page) {
+	if p != nil && atomic.LoadInt32(&p.shared) == 0 {
+		atomic.StoreInt32(&p.shared, 1)
+	}
+}
+
+// visitPage calls visit with the cells of page index in use below length
+func visitPage(index int, p *page, length int, visit func(addr int, values []int) bool) bool {
+	addr := index << pageBits
+	end := pageSize
+	if length-addr < end {
+		end = length - addr
+	}
+
+	return visit(addr, p.cells[:end])
+}
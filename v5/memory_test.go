@@ -0,0 +1,259 @@
+package intcode
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+func TestCopyOnWrite(t *testing.T) {
+	wg := new(sync.WaitGroup)
+	ic := CreateWithMemory(wg, NewPagedMemory(nil), 0, 0)
+	for addr := 0; addr < 3*pageSize; addr++ {
+		if err := Set(ic, addr, addr); err != nil {
+			t.Fatalf(`TestCopyOnWrite: failed to set address %v: %v`, addr, err)
+		}
+	}
+
+	fork := Copy(ic)
+	if err := Set(fork, 5, -5); err != nil {
+		t.Fatalf(`TestCopyOnWrite: failed to set fork: %v`, err)
+	}
+	if err := Set(ic, pageSize+5, -6); err != nil {
+		t.Fatalf(`TestCopyOnWrite: failed to set source: %v`, err)
+	}
+
+	if Get(ic, 5) != 5 || Get(fork, 5) != -5 {
+		t.Fatalf(`TestCopyOnWrite: address 5 is %v and %v, want 5 and -5`, Get(ic, 5), Get(fork, 5))
+	}
+	if Get(ic, pageSize+5) != -6 || Get(fork, pageSize+5) != pageSize+5 {
+		t.Fatalf(`TestCopyOnWrite: address %v is %v and %v, want -6 and %v`, pageSize+5, Get(ic, pageSize+5), Get(fork, pageSize+5), pageSize+5)
+	}
+
+	paged := fork.memory.(*pagedMemory)
+	source := ic.memory.(*pagedMemory)
+	if paged.pages[2] != source.pages[2] {
+		t.Fatalf(`TestCopyOnWrite: unwritten page was copied`)
+	}
+	if paged.pages[0] == source.pages[0] || paged.pages[1] == source.pages[1] {
+		t.Fatalf(`TestCopyOnWrite: written page is shared`)
+	}
+}
+
+func TestCopyOnWriteGrow(t *testing.T) {
+	wg := new(sync.WaitGroup)
+	ic, err := CreateLoad(wg, "./test_input/TstProg1", 0, 0)
+	if err != nil {
+		t.Fatalf(`TestCopyOnWriteGrow: failed to load program: %v`, err)
+	}
+	size := Size(ic)
+	original := ic.memory.Cells()
+
+	fork := Copy(ic)
+	if err := Set(fork, 5*pageSize, 1); err != nil {
+		t.Fatalf(`TestCopyOnWriteGrow: failed to set fork: %v`, err)
+	}
+
+	if Size(ic) != size || Size(fork) != 5*pageSize+1 {
+		t.Fatalf(`TestCopyOnWriteGrow: sizes %v and %v, want %v and %v`, Size(ic), Size(fork), size, 5*pageSize+1)
+	}
+	if Get(ic, 5*pageSize) != 0 || Get(fork, 5*pageSize-1) != 0 {
+		t.Fatalf(`TestCopyOnWriteGrow: unset addresses not zero`)
+	}
+
+	err = testRunCopy(fork)
+	if err != nil {
+		t.Fatalf(`TestCopyOnWriteGrow: fork failed to run: %v`, err)
+	}
+	for addr := 0; addr < size; addr++ {
+		if Get(ic, addr) != original[addr] {
+			t.Fatalf(`TestCopyOnWriteGrow: source memory changed at address %v`, addr)
+		}
+	}
+}
+
+func TestCopyConcurrent(t *testing.T) {
+	backends := map[string]Memory{
+		"dense":  NewDenseMemory(nil),
+		"paged":  NewPagedMemory(nil),
+		"sparse": NewSparseMemory(nil),
+	}
+
+	for name, mem := range backends {
+		template := CreateWithMemory(nil, mem, 0, 0)
+		if err := Load(template, "./test_input/TstProg3"); err != nil {
+			t.Fatalf(`TestCopyConcurrent: %v failed to load program: %v`, name, err)
+		}
+
+		var wg sync.WaitGroup
+		errs := make([]error, 8)
+		results := make([]int, 8)
+		for i := range errs {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+
+				fork := Copy(template)
+				errs[i] = testRunCopy(fork)
+				results[i] = Get(fork, 5)
+			}(i)
+		}
+		wg.Wait()
+
+		for i := range errs {
+			if errs[i] != nil || results[i] != 9801 {
+				t.Fatalf(`TestCopyConcurrent: %v copy %v returned %v %v, want 9801`, name, i, results[i], errs[i])
+			}
+		}
+		if Get(template, 5) != 0 {
+			t.Fatalf(`TestCopyConcurrent: %v template changed to %v, want 0`, name, Get(template, 5))
+		}
+		if template.memory != mem {
+			t.Fatalf(`TestCopyConcurrent: %v template memory was replaced`, name)
+		}
+		if _, dense := mem.(*denseMemory); dense {
+			if _, ok := Copy(template).memory.(*denseMemory); !ok {
+				t.Fatalf(`TestCopyConcurrent: copy of dense memory is not dense`)
+			}
+		}
+	}
+}
+
+func testRunCopy(ic *IntCode) error {
+	for {
+		_, state, err := RunUntil(ic)
+		if err != nil || state == StateHalted {
+			return err
+		}
+	}
+}
+
+// benchmarkMemory is the size of the memory image used by the fork benchmarks
+const benchmarkMemory = 64 * pageSize
+
+func benchmarkSource(mem Memory) *IntCode {
+	ic := CreateWithMemory(nil, mem, 0, 0)
+	for addr := 0; addr < benchmarkMemory; addr++ {
+		Set(ic, addr, addr)
+	}
+
+	return ic
+}
+
+// BenchmarkForkDeepCopy measures forking dense memory with Copy, which duplicates the full memory image
+func BenchmarkForkDeepCopy(b *testing.B) {
+	ic := benchmarkSource(NewDenseMemory(nil))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fork := Copy(ic)
+		Set(fork, i%benchmarkMemory, i)
+	}
+}
+
+// BenchmarkForkCopyOnWrite measures forking paged memory with Copy, where only the written page is duplicated
+func BenchmarkForkCopyOnWrite(b *testing.B) {
+	ic := benchmarkSource(NewPagedMemory(nil))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fork := Copy(ic)
+		Set(fork, i%benchmarkMemory, i)
+	}
+}
+
+func TestSparseMemoryFarAddress(t *testing.T) {
+	wg := new(sync.WaitGroup)
+	ic := CreateWithMemory(wg, NewSparseMemory(nil), 0, 0)
+	if err := Load(ic, "./test_input/TstProgFar"); err != nil {
+		t.Fatalf(`TestSparseMemoryFarAddress: failed to load program: %v`, err)
+	}
+
+	value, state, err := RunUntil(ic)
+	if err != nil || state != StateOutput || value != 11 {
+		t.Fatalf(`TestSparseMemoryFarAddress: returned %v %v %v, want 11 %v <nil>`, value, state, err, StateOutput)
+	}
+	if Get(ic, 1000000000) != 11 || Size(ic) != 1000000001 {
+		t.Fatalf(`TestSparseMemoryFarAddress: address 1000000000 is %v size %v, want 11 size 1000000001`, Get(ic, 1000000000), Size(ic))
+	}
+	if pages := len(ic.memory.(*sparseMemory).pages); pages != 2 {
+		t.Fatalf(`TestSparseMemoryFarAddress: allocated %v pages, want 2`, pages)
+	}
+
+	fork := Copy(ic)
+	Set(fork, 1000000000, 12)
+	if Get(ic, 1000000000) != 11 || Get(fork, 1000000000) != 12 {
+		t.Fatalf(`TestSparseMemoryFarAddress: copies have %v and %v, want 11 and 12`, Get(ic, 1000000000), Get(fork, 1000000000))
+	}
+}
+
+func TestMemoryBackends(t *testing.T) {
+	backends := map[string]Memory{
+		"dense":  NewDenseMemory(nil),
+		"paged":  NewPagedMemory(nil),
+		"sparse": NewSparseMemory(nil),
+	}
+
+	for name, mem := range backends {
+		wg := new(sync.WaitGroup)
+		ic := CreateWithMemory(wg, mem, 0, 0)
+		if err := Load(ic, "./test_input/TstProg3"); err != nil {
+			t.Fatalf(`TestMemoryBackends: %v failed to load program: %v`, name, err)
+		}
+
+		if err := testRunCopy(ic); err != nil {
+			t.Fatalf(`TestMemoryBackends: %v failed to run: %v`, name, err)
+		}
+		if Get(ic, 0) != 2 || Get(ic, 5) != 9801 {
+			t.Fatalf(`TestMemoryBackends: %v returned %v and %v, want 2 and 9801`, name, Get(ic, 0), Get(ic, 5))
+		}
+	}
+}
+
+func TestSparseMemoryFarAddressAPI(t *testing.T) {
+	ic := CreateWithMemory(nil, NewSparseMemory(nil), 0, 0)
+	if err := Load(ic, "./test_input/TstProgFar"); err != nil {
+		t.Fatalf(`TestSparseMemoryFarAddressAPI: failed to load program: %v`, err)
+	}
+	Set(ic, 1000000000, 3)
+
+	for _, format := range []SnapshotFormat{SnapshotBinary, SnapshotJSON} {
+		var buf bytes.Buffer
+		if err := Snapshot(ic, &buf, format); err != nil {
+			t.Fatalf(`TestSparseMemoryFarAddressAPI: format %v returned error: %v`, format, err)
+		}
+		if buf.Len() > 4*pageSize*8 {
+			t.Fatalf(`TestSparseMemoryFarAddressAPI: format %v snapshot is %v bytes`, format, buf.Len())
+		}
+
+		restored := CreateWithMemory(nil, NewSparseMemory(nil), 0, 0)
+		if err := Restore(restored, &buf); err != nil {
+			t.Fatalf(`TestSparseMemoryFarAddressAPI: format %v restore returned error: %v`, format, err)
+		}
+		if Size(restored) != Size(ic) || Get(restored, 1000000000) != 3 || Get(restored, 3) != 1000000000 {
+			t.Fatalf(`TestSparseMemoryFarAddressAPI: format %v restored size %v`, format, Size(restored))
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := Dump(ic, &buf, DumpTable, false); err != nil {
+		t.Fatalf(`TestSparseMemoryFarAddressAPI: dump returned error: %v`, err)
+	}
+	want := "" +
+		"      addr          +0         +1         +2         +3         +4         +5         +6         +7\n" +
+		"0000000000:      21101          5          6 1000000000        109  999999990        204         10  |....m...|\n" +
+		"0000000008:         99          0          0          0          0          0          0          0  |c.......|\n" +
+		"0000000016:          0          0          0          0          0          0          0          0  |........|\n" +
+		"*\n" +
+		"1000000000:          3                                                                               |.|\n"
+	if buf.String() != want {
+		t.Fatalf(`TestSparseMemoryFarAddressAPI: dump returned %q, want %q`, buf.String(), want)
+	}
+
+	outputs, err := Func(ic, false)()
+	if err != nil || len(outputs) != 1 || outputs[0] != 11 {
+		t.Fatalf(`TestSparseMemoryFarAddressAPI: function returned %v %v, want [11]`, outputs, err)
+	}
+}
@@ -0,0 +1,244 @@
+// Package network runs intcode computers which exchange packets of (address, X, Y)
+//
+// Each machine is given its address as its first input when it boots. Every three values a machine
+// outputs form a packet which is queued as input for the destination machine. A machine asking for
+// input with nothing queued is given -1. Packets sent to the NAT address go to a NAT which is asked
+// to wake the network whenever it goes idle.
+//
+// Machines are run in turn on a single goroutine with the synchronous intcode API, so idleness is
+// detected deterministically from the machines' behaviour rather than from timing
+package network
+
+import (
+	"fmt"
+
+	intcode "github.com/jblashki/aoc-intcode-go/v5"
+)
+
+//////////////////////
+// Consts and types //
+//////////////////////
+
+// NoInput is the value given to a machine that asks for input while its queue is empty
+const NoInput = -1
+
+// defaultIdleRounds is the number of quiet rounds before the network is considered idle
+const defaultIdleRounds = 2
+
+// Packet is a message sent between machines
+type Packet struct {
+	Dest int
+	X    int
+	Y    int
+}
+
+// NAT monitors a network, receiving packets sent to its address and waking the network when it is idle
+type NAT interface {
+	// Receive is called for each packet sent to the NAT address. Returning false stops the network
+	Receive(packet Packet) bool
+	// Idle is called when the network is idle. Returns the packet to send to wake it, or false to stop the network
+	Idle() (Packet, bool)
+}
+
+// Network is a set of intcode computers exchanging packets
+type Network struct {
+	machines   []*intcode.IntCode
+	nat        NAT
+	natAddr    int
+	idleRounds int
+	outbox     [][]int
+	delivered  []bool
+	halted     []bool
+}
+
+// DefaultNAT remembers the last packet it received and sends it to address 0 when the network is idle.
+// It stops the network when it sends the same Y value to address 0 twice in a row
+type DefaultNAT struct {
+	// Last is the last packet received
+	Last Packet
+	// Received is true once a packet has been received
+	Received bool
+	// Sent are the packets sent to wake the network
+	Sent []Packet
+}
+
+////////////////////////
+// Exported functions //
+////////////////////////
+
+// Create creates a network of machines with addresses matching their index. Packets sent to natAddr go to nat.
+// Each machine is queued its address as its first input
+func Create(machines []*intcode.IntCode, natAddr int, nat NAT) *Network {
+	n := &Network{
+		machines:   machines,
+		nat:        nat,
+		natAddr:    natAddr,
+		idleRounds: defaultIdleRounds,
+		outbox:     make([][]int, len(machines)),
+		delivered:  make([]bool, len(machines)),
+		halted:     make([]bool, len(machines)),
+	}
+
+	for addr, ic := range machines {
+		intcode.Feed(ic, addr)
+	}
+
+	return n
+}
+
+// SetIdleRounds sets how many consecutive rounds without any packet being sent or received
+// are needed before the network is considered idle
+func SetIdleRounds(n *Network, rounds int) {
+	n.idleRounds = rounds
+}
+
+// Send queues a packet for its destination machine or passes it to the NAT.
+// Returns false if the NAT asked for the network to stop
+func Send(n *Network, packet Packet) (bool, error) {
+	if packet.Dest == n.natAddr {
+		return n.nat.Receive(packet), nil
+	}
+
+	if packet.Dest < 0 || packet.Dest >= len(n.machines) {
+		return false, fmt.Errorf("Packet sent to unknown address %v", packet.Dest)
+	}
+
+	intcode.Feed(n.machines[packet.Dest], packet.X, packet.Y)
+	n.delivered[packet.Dest] = true
+
+	return true, nil
+}
+
+// Run runs the network until the NAT stops it, every machine halts or a machine fails.
+// Each round gives every machine a turn, running until it sends no more output and asks for
+// input a second time with nothing queued
+func Run(n *Network) error {
+	quiet := 0
+
+	for {
+		active := false
+		running := 0
+
+		for addr, ic := range n.machines {
+			if n.halted[addr] {
+				continue
+			}
+			running++
+
+			if n.delivered[addr] {
+				active = true
+				n.delivered[addr] = false
+			}
+
+			sent, carryOn, err := turn(n, addr, ic)
+			if err != nil {
+				return err
+			}
+			if !carryOn {
+				return nil
+			}
+			if sent {
+				active = true
+			}
+		}
+
+		if running == 0 {
+			return nil
+		}
+
+		if active {
+			quiet = 0
+			continue
+		}
+
+		quiet++
+		if quiet < n.idleRounds {
+			continue
+		}
+
+		packet, wake := n.nat.Idle()
+		if !wake {
+			return nil
+		}
+		carryOn, err := Send(n, packet)
+		if err != nil {
+			return err
+		}
+		if !carryOn {
+			return nil
+		}
+		quiet = 0
+	}
+}
+
+// Receive remembers the packet as the last received
+func (nat *DefaultNAT) Receive(packet Packet) bool {
+	nat.Last = packet
+	nat.Received = true
+
+	return true
+}
+
+// Idle sends the last packet received to address 0, stopping if its Y value was the last one sent
+func (nat *DefaultNAT) Idle() (Packet, bool) {
+	if !nat.Received {
+		return Packet{}, false
+	}
+
+	packet := Packet{Dest: 0, X: nat.Last.X, Y: nat.Last.Y}
+	if len(nat.Sent) > 0 && nat.Sent[len(nat.Sent)-1].Y == packet.Y {
+		return Packet{}, false
+	}
+
+	nat.Sent = append(nat.Sent, packet)
+
+	return packet, true
+}
+
+//////////////////////////
+// Unexported functions //
+//////////////////////////
+
+// turn runs one machine until it asks for input twice with nothing queued, halts or fails.
+// Returns whether it sent a packet and whether the network should carry on
+func turn(n *Network, addr int, ic *intcode.IntCode) (sent bool, carryOn bool, err error) {
+	polled := false
+
+	for {
+		value, state, err := intcode.RunUntil(ic)
+
+		switch state {
+		case intcode.StateOutput:
+			n.outbox[addr] = append(n.outbox[addr], value)
+			if len(n.outbox[addr]) < 3 {
+				continue
+			}
+
+			packet := Packet{n.outbox[addr][0], n.outbox[addr][1], n.outbox[addr][2]}
+			n.outbox[addr] = n.outbox[addr][:0]
+			sent = true
+
+			carryOn, err := Send(n, packet)
+			if err != nil {
+				return sent, false, fmt.Errorf("Machine %v: %w", addr, err)
+			}
+			if !carryOn {
+				return sent, false, nil
+			}
+
+		case intcode.StateInput:
+			if polled {
+				return sent, true, nil
+			}
+			intcode.Feed(ic, NoInput)
+			polled = true
+
+		case intcode.StateHalted:
+			n.halted[addr] = true
+			return sent, true, nil
+
+		case intcode.StateError:
+			return sent, false, fmt.Errorf("Machine %v: %w", addr, err)
+		}
+	}
+}
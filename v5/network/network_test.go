@@ -0,0 +1,88 @@
+package network
+
+import (
+	"sync"
+	"testing"
+
+	intcode "github.com/jblashki/aoc-intcode-go/v5"
+)
+
+// countingNAT wakes the network with the last packet it received until it has received limit packets
+type countingNAT struct {
+	received []Packet
+	idles    int
+	limit    int
+}
+
+func (nat *countingNAT) Receive(packet Packet) bool {
+	nat.received = append(nat.received, packet)
+
+	return len(nat.received) < nat.limit
+}
+
+func (nat *countingNAT) Idle() (Packet, bool) {
+	nat.idles++
+	last := nat.received[len(nat.received)-1]
+
+	return Packet{Dest: 0, X: last.X, Y: last.Y}, true
+}
+
+func TestForwarding(t *testing.T) {
+	nat := &countingNAT{limit: 3}
+	n := Create(loadMachines(t, "../test_input/TstProgNetForward", 4), 255, nat)
+
+	if err := Run(n); err != nil {
+		t.Fatalf(`TestForwarding: returned error: %v`, err)
+	}
+
+	want := []Packet{{255, 7, 3}, {255, 7, 7}, {255, 7, 11}}
+	if len(nat.received) != len(want) {
+		t.Fatalf(`TestForwarding: NAT received %v, want %v`, nat.received, want)
+	}
+	for i := range want {
+		if nat.received[i] != want[i] {
+			t.Fatalf(`TestForwarding: NAT received %v, want %v`, nat.received, want)
+		}
+	}
+	if nat.idles != 2 {
+		t.Fatalf(`TestForwarding: NAT woke the network %v times, want 2`, nat.idles)
+	}
+}
+
+func TestDefaultNAT(t *testing.T) {
+	nat := new(DefaultNAT)
+	n := Create(loadMachines(t, "../test_input/TstProgNetEcho", 3), 255, nat)
+
+	if err := Run(n); err != nil {
+		t.Fatalf(`TestDefaultNAT: returned error: %v`, err)
+	}
+
+	if len(nat.Sent) != 1 || nat.Sent[0] != (Packet{0, 7, 5}) {
+		t.Fatalf(`TestDefaultNAT: NAT sent %v, want [{0 7 5}]`, nat.Sent)
+	}
+	if nat.Last != (Packet{255, 7, 5}) {
+		t.Fatalf(`TestDefaultNAT: NAT last received %v, want {255 7 5}`, nat.Last)
+	}
+}
+
+func TestUnknownAddress(t *testing.T) {
+	nat := new(DefaultNAT)
+	n := Create(loadMachines(t, "../test_input/TstProgNetForward", 2), 255, nat)
+
+	if err := Run(n); err == nil {
+		t.Fatalf(`TestUnknownAddress: returned no error for packet to address 2 of 2 machines`)
+	}
+}
+
+func loadMachines(t *testing.T, progFile string, n int) []*intcode.IntCode {
+	machines := make([]*intcode.IntCode, n)
+	for i := range machines {
+		ic, err := intcode.CreateLoad(new(sync.WaitGroup), progFile, 0, 0)
+		if err != nil {
+			t.Fatalf(`Failed to load program %v: %v`, progFile, err)
+		}
+		machines[i] = ic
+	}
+
+	return machines
+}
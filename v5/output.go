@@ -0,0 +1,106 @@
+package intcode
+
+import "context"
+
+// OutputConsumer receives the values of output instructions. When an intcode has a consumer its outputs are
+// passed to it instead of being returned by Step and RunUntil or sent to Read, and execution carries on
+type OutputConsumer interface {
+	Output(value int) error
+}
+
+// outputWaiter is implemented by consumers which block until a value is taken by another goroutine. The intcode
+// calls it instead of Output so it can stop waiting when it is cancelled or closed
+type outputWaiter interface {
+	// waitOutput blocks until value is taken, returning ctx.Err() if ctx is done or ErrClosed if done is closed
+	waitOutput(ctx context.Context, done <-chan struct{}, value int) error
+}
+
+// SliceOutput collects output values
+type SliceOutput struct {
+	// Values are the values output so far
+	Values []int
+}
+
+// ChanOutput sends output values to a channel, blocking until each is received. A running intcode stops waiting
+// when its context is done or it is closed, leaving the output instruction to be executed again
+type ChanOutput struct {
+	ch chan<- int
+}
+
+// OutputFunc consumes output values by calling a function
+type OutputFunc func(value int) error
+
+// TupleOutput groups output values into records of a fixed size, e.g. (x, y, tile) or (dest, x, y)
+type TupleOutput struct {
+	size    int
+	deliver func(record []int) error
+	pending []int
+}
+
+// SetOutput sets the consumer of output values. nil restores returning outputs from Step and RunUntil
+func SetOutput(ic *IntCode, consumer OutputConsumer) {
+	ic.consumer = consumer
+}
+
+// Output appends a value
+func (c *SliceOutput) Output(value int) error {
+	c.Values = append(c.Values, value)
+
+	return nil
+}
+
+// NewChanOutput creates a consumer sending to ch
+func NewChanOutput(ch chan<- int) *ChanOutput {
+	return &ChanOutput{ch}
+}
+
+// Output sends a value on the channel
+func (c *ChanOutput) Output(value int) error {
+	c.ch <- value
+
+	return nil
+}
+
+func (c *ChanOutput) waitOutput(ctx context.Context, done <-chan struct{}, value int) error {
+	select {
+	case c.ch <- value:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-done:
+		return ErrClosed
+	}
+}
+
+// Output calls the function with a value
+func (f OutputFunc) Output(value int) error {
+	return f(value)
+}
+
+// NewTupleOutput creates a consumer calling deliver with every size values output. A size below 1
+// delivers every value as a record of its own
+func NewTupleOutput(size int, deliver func(record []int) error) *TupleOutput {
+	if size < 1 {
+		size = 1
+	}
+
+	return &TupleOutput{size: size, deliver: deliver}
+}
+
+// Output adds a value to the current record, delivering it once complete
+func (c *TupleOutput) Output(value int) error {
+	c.pending = append(c.pending, value)
+	if len(c.pending) < c.size {
+		return nil
+	}
+
+	record := c.pending
+	c.pending = nil
+
+	return c.deliver(record)
+}
+
+// Pending returns the values of the incomplete record
+func (c *TupleOutput) Pending() []int {
+	return append([]int(nil), c.pending...)
+}
@@ -0,0 +1,192 @@
+package intcode
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSliceOutput(t *testing.T) {
+	ic, err := CreateLoad(new(sync.WaitGroup), "./test_input/TstProgInputOutput2", 0, 0)
+	if err != nil {
+		t.Fatalf(`TestSliceOutput: failed to load program: %v`, err)
+	}
+
+	collector := new(SliceOutput)
+	SetOutput(ic, collector)
+	Feed(ic, 5, 3, 10, 4)
+
+	_, state, err := RunUntil(ic)
+	if err != nil || state != StateHalted {
+		t.Fatalf(`TestSliceOutput: returned (%v, %v), want (%v, nil)`, state, err, StateHalted)
+	}
+
+	if len(collector.Values) != 2 || collector.Values[0] != 8 || collector.Values[1] != 40 {
+		t.Fatalf(`TestSliceOutput: collected %v, want [8 40]`, collector.Values)
+	}
+}
+
+func TestTupleOutput(t *testing.T) {
+	ic, err := CreateLoad(new(sync.WaitGroup), "./test_input/TstProgNetForward", 0, 0)
+	if err != nil {
+		t.Fatalf(`TestTupleOutput: failed to load program: %v`, err)
+	}
+
+	records := make([][]int, 0)
+	tuples := NewTupleOutput(3, func(record []int) error {
+		records = append(records, record)
+		return nil
+	})
+	SetOutput(ic, tuples)
+	SetInput(ic, NewSliceInput(0))
+
+	_, state, err := RunUntil(ic)
+	if err != nil || state != StateInput {
+		t.Fatalf(`TestTupleOutput: returned (%v, %v), want (%v, nil)`, state, err, StateInput)
+	}
+
+	if len(records) != 1 || records[0][0] != 1 || records[0][1] != 7 || records[0][2] != 0 {
+		t.Fatalf(`TestTupleOutput: delivered %v, want [[1 7 0]]`, records)
+	}
+	if len(tuples.Pending()) != 0 {
+		t.Fatalf(`TestTupleOutput: left %v pending, want none`, tuples.Pending())
+	}
+}
+
+func TestTupleOutputSingle(t *testing.T) {
+	ic, err := CreateLoad(new(sync.WaitGroup), "./test_input/TstProgInputOutput", 0, 0)
+	if err != nil {
+		t.Fatalf(`TestTupleOutputSingle: failed to load program: %v`, err)
+	}
+
+	records := make([][]int, 0)
+	SetOutput(ic, NewTupleOutput(0, func(record []int) error {
+		records = append(records, record)
+		return nil
+	}))
+	Feed(ic, 7)
+
+	_, state, err := RunUntil(ic)
+	if err != nil || state != StateHalted {
+		t.Fatalf(`TestTupleOutputSingle: returned (%v, %v), want (%v, nil)`, state, err, StateHalted)
+	}
+
+	if len(records) != 1 || len(records[0]) != 1 || records[0][0] != 7 {
+		t.Fatalf(`TestTupleOutputSingle: delivered %v, want [[7]]`, records)
+	}
+}
+
+func TestOutputFuncError(t *testing.T) {
+	wg := new(sync.WaitGroup)
+	ic, err := CreateLoad(wg, "./test_input/TstProgInputOutput", 0, 0)
+	if err != nil {
+		t.Fatalf(`TestOutputFuncError: failed to load program: %v`, err)
+	}
+	defer Close(ic)
+
+	errFull := errors.New("full")
+	SetOutput(ic, OutputFunc(func(value int) error {
+		return errFull
+	}))
+
+	wg.Add(1)
+	go Run(ic, "")
+
+	if _, sig, _ := Read(ic); sig != SigInput {
+		t.Fatalf(`TestOutputFuncError: returned %v, want %v`, sig, SigInput)
+	}
+	Write(ic, 1)
+
+	_, sig, err := Read(ic)
+	if sig != SigError || !errors.Is(err, errFull) {
+		t.Fatalf(`TestOutputFuncError: returned (%v, %v), want (%v, %v)`, sig, err, SigError, errFull)
+	}
+	wg.Wait()
+}
+
+func TestChanOutputCancel(t *testing.T) {
+	ic, err := CreateLoad(nil, "./test_input/TstProgInputOutput", 0, 0)
+	if err != nil {
+		t.Fatalf(`TestChanOutputCancel: failed to load program: %v`, err)
+	}
+	Feed(ic, 7)
+
+	ch := make(chan int)
+	SetOutput(ic, NewChanOutput(ch))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	result := make(chan error, 1)
+	go func() {
+		result <- RunContext(ctx, ic)
+	}()
+
+	select {
+	case err := <-result:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf(`TestChanOutputCancel: returned %v, want deadline exceeded`, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf(`TestChanOutputCancel: still waiting to output after deadline`)
+	}
+	if Pos(ic) != 2 {
+		t.Fatalf(`TestChanOutputCancel: stopped @ address %v, want 2`, Pos(ic))
+	}
+
+	ic, err = CreateLoad(nil, "./test_input/TstProgInputOutput", 0, 0)
+	if err != nil {
+		t.Fatalf(`TestChanOutputCancel: failed to load program: %v`, err)
+	}
+	Feed(ic, 7)
+	SetOutput(ic, NewChanOutput(ch))
+
+	go func() {
+		result <- RunContext(context.Background(), ic)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	Close(ic)
+
+	select {
+	case err := <-result:
+		if !errors.Is(err, ErrClosed) {
+			t.Fatalf(`TestChanOutputCancel: returned %v after close, want ErrClosed`, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf(`TestChanOutputCancel: still waiting to output after close`)
+	}
+}
+
+func TestCopyConsumers(t *testing.T) {
+	ic, err := CreateLoad(nil, "./test_input/TstProgInputOutput", 0, 0)
+	if err != nil {
+		t.Fatalf(`TestCopyConsumers: failed to load program: %v`, err)
+	}
+
+	source := new(SliceOutput)
+	SetOutput(ic, source)
+	SetInput(ic, NewSliceInput(1))
+
+	fork := Copy(ic)
+	unset := Copy(ic)
+	forkOutput := new(SliceOutput)
+	SetOutput(fork, forkOutput)
+	SetInput(fork, NewSliceInput(2))
+
+	for _, m := range []*IntCode{ic, fork} {
+		if _, state, err := RunUntil(m); err != nil || state != StateHalted {
+			t.Fatalf(`TestCopyConsumers: returned (%v, %v), want %v`, state, err, StateHalted)
+		}
+	}
+
+	if len(source.Values) != 1 || source.Values[0] != 1 || len(forkOutput.Values) != 1 || forkOutput.Values[0] != 2 {
+		t.Fatalf(`TestCopyConsumers: returned %v and %v, want [1] and [2]`, source.Values, forkOutput.Values)
+	}
+
+	Feed(unset, 3)
+	if value, state, err := RunUntil(unset); err != nil || state != StateOutput || value != 3 {
+		t.Fatalf(`TestCopyConsumers: copy returned (%v, %v, %v), want (3, %v, nil)`, value, state, err, StateOutput)
+	}
+}
@@ -0,0 +1,111 @@
+package intcode
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ErrEmptyValue is the error of a ParseError for a comma with no value before it
+var ErrEmptyValue = errors.New("empty value")
+
+// ParseError is returned when a program contains a malformed value
+type ParseError struct {
+	// Line is the line of the value starting from 1
+	Line int
+	// Col is the column of the first character of the value starting from 1
+	Col int
+	// Token is the malformed value
+	Token string
+	// Err is the underlying conversion error
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("Invalid value %q at line %v column %v: %v", e.Token, e.Line, e.Col, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// parseProgram reads comma or whitespace separated values from r, skipping # comments. A comma must follow
+// a value on the same line, so a missing value is reported rather than shifting the addresses after it
+func parseProgram(r io.Reader) ([]int, error) {
+	br := bufio.NewReader(r)
+	values := make([]int, 0)
+
+	line, col := 1, 0
+	token := make([]byte, 0, 32)
+	tokenLine, tokenCol := 0, 0
+	comment := false
+	afterValue := false
+
+	flush := func() error {
+		if len(token) == 0 {
+			return nil
+		}
+
+		value, err := strconv.Atoi(string(token))
+		if err != nil {
+			return &ParseError{Line: tokenLine, Col: tokenCol, Token: string(token), Err: err}
+		}
+
+		values = append(values, value)
+		token = token[:0]
+		afterValue = true
+
+		return nil
+	}
+
+	for {
+		c, err := br.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		col++
+
+		switch {
+		case c == '\n':
+			comment = false
+			fallthrough
+		case comment:
+		case c == ',' || c == ' ' || c == '\t' || c == '\r':
+		case c == '#':
+			comment = true
+		default:
+			if len(token) == 0 {
+				tokenLine, tokenCol = line, col
+			}
+			token = append(token, c)
+			continue
+		}
+
+		if err := flush(); err != nil {
+			return nil, err
+		}
+
+		switch {
+		case c == '\n':
+			line, col = line+1, 0
+			afterValue = false
+		case comment:
+		case c == ',':
+			if !afterValue {
+				return nil, &ParseError{Line: line, Col: col, Err: ErrEmptyValue}
+			}
+			afterValue = false
+		}
+	}
+
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
@@ -0,0 +1,123 @@
+package intcode
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestLoadCommented(t *testing.T) {
+	wg := new(sync.WaitGroup)
+	ic, err := CreateLoad(wg, "./test_input/TstProgCommented", 0, 0)
+	if err != nil {
+		t.Fatalf(`TestLoadCommented: failed to load program: %v`, err)
+	}
+
+	want := []int{1101, 5, 6, 7, 4, 7, 99, 0}
+	if Size(ic) != len(want) {
+		t.Fatalf(`TestLoadCommented: loaded %v values, want %v`, Size(ic), len(want))
+	}
+	for addr, value := range want {
+		if Get(ic, addr) != value {
+			t.Fatalf(`TestLoadCommented: address %v is %v, want %v`, addr, Get(ic, addr), value)
+		}
+	}
+
+	value, _, err := RunUntil(ic)
+	if err != nil || value != 11 {
+		t.Fatalf(`TestLoadCommented: returned %v %v, want 11`, value, err)
+	}
+}
+
+func TestLoadMalformed(t *testing.T) {
+	ic := Create(nil, 0, 0)
+	err := Load(ic, "./test_input/TstProgMalformed")
+
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf(`TestLoadMalformed: returned %v, want ParseError`, err)
+	}
+	if parseErr.Line != 2 || parseErr.Col != 4 || parseErr.Token != "1O" {
+		t.Fatalf(`TestLoadMalformed: error at line %v column %v token %q, want line 2 column 4 token "1O"`, parseErr.Line, parseErr.Col, parseErr.Token)
+	}
+	if !errors.Is(err, strconv.ErrSyntax) {
+		t.Fatalf(`TestLoadMalformed: returned %v, want strconv.ErrSyntax`, err)
+	}
+}
+
+func TestLoadString(t *testing.T) {
+	tests := []struct {
+		program string
+		want    []int
+	}{
+		{"1,2,3", []int{1, 2, 3}},
+		{"1,2,3,\n", []int{1, 2, 3}},
+		{"  -1 ,\r\n+2\t3 # comment, 4\n", []int{-1, 2, 3}},
+		{"#1,2\n\n", []int{}},
+		{"", []int{}},
+	}
+
+	for _, test := range tests {
+		ic := Create(nil, 0, 0)
+		if err := LoadString(ic, test.program); err != nil {
+			t.Fatalf(`TestLoadString: %q returned error: %v`, test.program, err)
+		}
+
+		if Size(ic) != len(test.want) {
+			t.Fatalf(`TestLoadString: %q loaded %v values, want %v`, test.program, Size(ic), len(test.want))
+		}
+		for addr, value := range test.want {
+			if Get(ic, addr) != value {
+				t.Fatalf(`TestLoadString: %q address %v is %v, want %v`, test.program, addr, Get(ic, addr), value)
+			}
+		}
+	}
+
+	ic := Create(nil, 0, 0)
+	err := LoadString(ic, "1,2\n3,99999999999999999999999")
+
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || parseErr.Line != 2 || parseErr.Col != 3 || !errors.Is(err, strconv.ErrRange) {
+		t.Fatalf(`TestLoadString: returned %v, want range ParseError at line 2 column 3`, err)
+	}
+}
+
+func TestLoadInts(t *testing.T) {
+	program := []int{1, 0, 0, 0, 99}
+	ic := Create(nil, 0, 0)
+	LoadInts(ic, program)
+
+	if _, _, err := RunUntil(ic); err != nil {
+		t.Fatalf(`TestLoadInts: returned error: %v`, err)
+	}
+	if Get(ic, 0) != 2 || program[0] != 1 {
+		t.Fatalf(`TestLoadInts: address 0 is %v and source %v, want 2 and 1`, Get(ic, 0), program[0])
+	}
+}
+
+func TestLoadEmptyValue(t *testing.T) {
+	tests := []struct {
+		program   string
+		line, col int
+	}{
+		{"1,,2", 1, 3},
+		{"1,2,\n,3", 2, 1},
+		{",1", 1, 1},
+		{"1, ,2", 1, 4},
+		{"1 # comment\n, 2", 2, 1},
+	}
+
+	for _, test := range tests {
+		ic := Create(nil, 0, 0)
+		err := LoadString(ic, test.program)
+
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) || !errors.Is(err, ErrEmptyValue) {
+			t.Fatalf(`TestLoadEmptyValue: %q returned %v, want empty value ParseError`, test.program, err)
+		}
+		if parseErr.Line != test.line || parseErr.Col != test.col {
+			t.Fatalf(`TestLoadEmptyValue: %q error at line %v column %v, want line %v column %v`, test.program, parseErr.Line, parseErr.Col, test.line, test.col)
+		}
+	}
+}
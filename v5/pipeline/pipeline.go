@@ -0,0 +1,165 @@
+// Package pipeline connects intcode computers together so the outputs of one feed the inputs of others
+//
+// Machines are run in turn on a single goroutine with the synchronous intcode API, so a network runs
+// deterministically and needs no channels or wait groups
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+
+	intcode "github.com/jblashki/aoc-intcode-go/v5"
+)
+
+//////////////////////
+// Consts and types //
+//////////////////////
+
+// ErrDeadlock is returned when every machine still running is waiting for input nobody will send
+var ErrDeadlock = errors.New("Pipeline deadlocked waiting for input")
+
+// Edge connects the outputs of machine From to the inputs of machine To
+type Edge struct {
+	From int
+	To   int
+}
+
+// Topology describes how machines are connected
+type Topology struct {
+	// Edges are the connections between machines. A machine with several outgoing edges sends each output to all of them
+	Edges []Edge
+	// Output is the machine whose outputs are the final outputs
+	Output int
+}
+
+// Result is the outcome of running a pipeline
+type Result struct {
+	// Outputs are the outputs of the Output machine of the topology
+	Outputs []int
+	// MachineOutputs are the outputs of each machine
+	MachineOutputs [][]int
+	// States are the states each machine stopped in
+	States []intcode.State
+	// Errors are the errors each machine failed with, nil if it did not fail
+	Errors []error
+}
+
+////////////////////////
+// Exported functions //
+////////////////////////
+
+// Chain connects n machines in a line, 0 to 1 to 2 and so on, with the last machine as output
+func Chain(n int) Topology {
+	topo := Topology{Output: n - 1}
+	for i := 0; i+1 < n; i++ {
+		topo.Edges = append(topo.Edges, Edge{i, i + 1})
+	}
+
+	return topo
+}
+
+// Ring connects n machines in a feedback loop, 0 to 1 and so on with the last machine feeding back to 0.
+// The last machine is the output
+func Ring(n int) Topology {
+	topo := Chain(n)
+	if n > 0 {
+		topo.Edges = append(topo.Edges, Edge{n - 1, 0})
+	}
+
+	return topo
+}
+
+// DAG connects machines with arbitrary edges, taking the final outputs from machine output
+func DAG(output int, edges ...Edge) Topology {
+	return Topology{Edges: edges, Output: output}
+}
+
+// Run runs machines connected by topo until every machine has halted or failed. inputs are queued on each
+// machine before it starts, such as phase settings or the initial signal, and may be shorter than machines.
+// Returns the first machine error, or ErrDeadlock if the machines still running all wait for input
+func Run(machines []*intcode.IntCode, topo Topology, inputs [][]int) (Result, error) {
+	n := len(machines)
+	result := Result{
+		MachineOutputs: make([][]int, n),
+		States:         make([]intcode.State, n),
+		Errors:         make([]error, n),
+	}
+
+	if topo.Output < 0 || topo.Output >= n {
+		return result, fmt.Errorf("Output machine %v out of range for %v machines", topo.Output, n)
+	}
+	targets := make([][]int, n)
+	for _, edge := range topo.Edges {
+		if edge.From < 0 || edge.From >= n || edge.To < 0 || edge.To >= n {
+			return result, fmt.Errorf("Edge %v -> %v out of range for %v machines", edge.From, edge.To, n)
+		}
+		targets[edge.From] = append(targets[edge.From], edge.To)
+	}
+
+	for i, values := range inputs {
+		if i < n {
+			intcode.Feed(machines[i], values...)
+		}
+	}
+
+	running := n
+	for running > 0 {
+		progress := false
+
+		for i, ic := range machines {
+			if finished(result.States[i]) {
+				continue
+			}
+
+			before := intcode.Steps(ic)
+			for {
+				value, state, err := intcode.RunUntil(ic)
+				result.States[i] = state
+
+				if state != intcode.StateOutput {
+					if state == intcode.StateError {
+						result.Errors[i] = err
+					}
+					break
+				}
+
+				result.MachineOutputs[i] = append(result.MachineOutputs[i], value)
+				for _, to := range targets[i] {
+					intcode.Feed(machines[to], value)
+				}
+			}
+
+			if finished(result.States[i]) {
+				running--
+				progress = true
+			} else if intcode.Steps(ic) != before {
+				progress = true
+			}
+		}
+
+		if !progress {
+			break
+		}
+	}
+
+	result.Outputs = result.MachineOutputs[topo.Output]
+
+	for i, err := range result.Errors {
+		if err != nil {
+			return result, fmt.Errorf("Machine %v: %w", i, err)
+		}
+	}
+	if running > 0 {
+		return result, ErrDeadlock
+	}
+
+	return result, nil
+}
+
+//////////////////////////
+// Unexported functions //
+//////////////////////////
+
+func finished(state intcode.State) bool {
+	return state == intcode.StateHalted || state == intcode.StateError
+}
@@ -0,0 +1,124 @@
+package pipeline
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	intcode "github.com/jblashki/aoc-intcode-go/v5"
+)
+
+func TestChain(t *testing.T) {
+	machines := loadMachines(t, "../test_input/TstProgAmp", 5)
+
+	result, err := Run(machines, Chain(5), [][]int{{4, 0}, {3}, {2}, {1}, {0}})
+	if err != nil {
+		t.Fatalf(`TestChain: returned error: %v`, err)
+	}
+
+	if len(result.Outputs) != 1 || result.Outputs[0] != 43210 {
+		t.Fatalf(`TestChain: returned %v, want [43210]`, result.Outputs)
+	}
+	for i, state := range result.States {
+		if state != intcode.StateHalted {
+			t.Fatalf(`TestChain: machine %v stopped in %v, want %v`, i, state, intcode.StateHalted)
+		}
+	}
+}
+
+func TestRing(t *testing.T) {
+	machines := loadMachines(t, "../test_input/TstProgAmpFeedback", 5)
+
+	result, err := Run(machines, Ring(5), [][]int{{9, 0}, {8}, {7}, {6}, {5}})
+	if err != nil {
+		t.Fatalf(`TestRing: returned error: %v`, err)
+	}
+
+	last := result.Outputs[len(result.Outputs)-1]
+	if last != 139629729 {
+		t.Fatalf(`TestRing: returned %v, want 139629729`, last)
+	}
+}
+
+func TestDAG(t *testing.T) {
+	// Two echo machines fan in to an adder
+	machines := loadMachines(t, "../test_input/TstProgInputOutput", 2)
+	machines = append(machines, loadMachines(t, "../test_input/TstProgInputOutput2", 1)...)
+
+	topo := DAG(2, Edge{0, 2}, Edge{1, 2})
+	result, err := Run(machines, topo, [][]int{{5}, {3}, {}})
+	if !errors.Is(err, ErrDeadlock) {
+		t.Fatalf(`TestDAG: returned %v, want %v`, err, ErrDeadlock)
+	}
+
+	if len(result.Outputs) != 1 || result.Outputs[0] != 8 {
+		t.Fatalf(`TestDAG: returned %v, want [8]`, result.Outputs)
+	}
+	if result.States[2] != intcode.StateInput {
+		t.Fatalf(`TestDAG: adder stopped in %v, want %v`, result.States[2], intcode.StateInput)
+	}
+}
+
+func loadMachines(t *testing.T, progFile string, n int) []*intcode.IntCode {
+	machines := make([]*intcode.IntCode, n)
+	for i := range machines {
+		ic, err := intcode.CreateLoad(new(sync.WaitGroup), progFile, 0, 0)
+		if err != nil {
+			t.Fatalf(`Failed to load program %v: %v`, progFile, err)
+		}
+		machines[i] = ic
+	}
+
+	return machines
+}
+
+func TestSearchChain(t *testing.T) {
+	template := loadMachines(t, "../test_input/TstProgAmp", 1)[0]
+
+	best, err := Search(template, []int{0, 1, 2, 3, 4}, Chain, 3)
+	if err != nil {
+		t.Fatalf(`TestSearchChain: returned error: %v`, err)
+	}
+
+	want := []int{4, 3, 2, 1, 0}
+	if best.Output != 43210 {
+		t.Fatalf(`TestSearchChain: returned %v, want 43210`, best.Output)
+	}
+	for i := range want {
+		if best.Phases[i] != want[i] {
+			t.Fatalf(`TestSearchChain: returned phases %v, want %v`, best.Phases, want)
+		}
+	}
+}
+
+func TestSearchRing(t *testing.T) {
+	template := loadMachines(t, "../test_input/TstProgAmpFeedback", 1)[0]
+
+	best, err := Search(template, []int{5, 6, 7, 8, 9}, Ring, 0)
+	if err != nil {
+		t.Fatalf(`TestSearchRing: returned error: %v`, err)
+	}
+
+	if best.Output != 139629729 {
+		t.Fatalf(`TestSearchRing: returned %v, want 139629729`, best.Output)
+	}
+}
+
+func TestPermute(t *testing.T) {
+	perms := make([][]int, 0)
+	permute([]int{1, 2, 3}, 0, func(perm []int) {
+		perms = append(perms, append([]int(nil), perm...))
+	})
+
+	want := [][]int{{1, 2, 3}, {1, 3, 2}, {2, 1, 3}, {2, 3, 1}, {3, 1, 2}, {3, 2, 1}}
+	if len(perms) != len(want) {
+		t.Fatalf(`TestPermute: returned %v, want %v`, perms, want)
+	}
+	for i := range want {
+		for j := range want[i] {
+			if perms[i][j] != want[i][j] {
+				t.Fatalf(`TestPermute: returned %v, want %v`, perms, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+
+	intcode "github.com/jblashki/aoc-intcode-go/v5"
+)
+
+// Best is the best phase setting found by Search
+type Best struct {
+	// Phases are the phase settings given to each machine in order
+	Phases []int
+	// Output is the final output signal produced by Phases
+	Output int
+}
+
+// searchJob is one permutation to try
+type searchJob struct {
+	index  int
+	phases []int
+}
+
+// searchResult is the outcome of one permutation
+type searchResult struct {
+	index  int
+	phases []int
+	output int
+	err    error
+}
+
+// Search runs every permutation of phases on copies of template connected by topology, e.g. Chain or Ring,
+// and returns the permutation giving the largest final output. Each machine is given its phase setting and
+// the first machine is also given an input signal of 0. Permutations are run on up to workers goroutines,
+// or one per CPU if workers is not positive. Ties go to the permutation generated first
+func Search(template *intcode.IntCode, phases []int, topology func(n int) Topology, workers int) (Best, error) {
+	if len(phases) == 0 {
+		return Best{}, errors.New("No phase settings to search")
+	}
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	jobs := make(chan searchJob)
+	results := make(chan searchResult)
+
+	go func() {
+		index := 0
+		permute(append([]int(nil), phases...), 0, func(perm []int) {
+			jobs <- searchJob{index, append([]int(nil), perm...)}
+			index++
+		})
+		close(jobs)
+	}()
+
+	wg := new(sync.WaitGroup)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				results <- runJob(template, job, topology)
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var best *searchResult
+	var failed *searchResult
+	for result := range results {
+		result := result
+		if result.err != nil {
+			if failed == nil || result.index < failed.index {
+				failed = &result
+			}
+			continue
+		}
+		if best == nil || result.output > best.output || (result.output == best.output && result.index < best.index) {
+			best = &result
+		}
+	}
+
+	if failed != nil {
+		return Best{}, fmt.Errorf("Phases %v: %w", failed.phases, failed.err)
+	}
+
+	return Best{Phases: best.phases, Output: best.output}, nil
+}
+
+// runJob runs copies of template with one permutation. Copy only reads the template so workers share it
+func runJob(template *intcode.IntCode, job searchJob, topology func(n int) Topology) searchResult {
+	machines := make([]*intcode.IntCode, len(job.phases))
+	inputs := make([][]int, len(job.phases))
+	for i, phase := range job.phases {
+		machines[i] = intcode.Copy(template)
+		inputs[i] = []int{phase}
+	}
+	inputs[0] = append(inputs[0], 0)
+
+	result, err := Run(machines, topology(len(machines)), inputs)
+	if err == nil && len(result.Outputs) == 0 {
+		err = errors.New("No output produced")
+	}
+	if err != nil {
+		return searchResult{index: job.index, phases: job.phases, err: err}
+	}
+
+	return searchResult{index: job.index, phases: job.phases, output: result.Outputs[len(result.Outputs)-1]}
+}
+
+// permute calls visit with every permutation of values[k:] in lexicographic order of position
+func permute(values []int, k int, visit func([]int)) {
+	if k == len(values) {
+		visit(values)
+		return
+	}
+
+	for i := k; i < len(values); i++ {
+		// Rotate values[i] into position k so the remaining values keep their order
+		value := values[i]
+		copy(values[k+1:i+1], values[k:i])
+		values[k] = value
+
+		permute(values, k+1, visit)
+
+		copy(values[k:i], values[k+1:i+1])
+		values[i] = value
+	}
+}
@@ -0,0 +1,115 @@
+package intcode
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Program is a parsed intcode program. It is never modified, so may be shared between goroutines and
+// instantiated any number of times. Instances share its memory pages until they write to them
+type Program struct {
+	memory Memory
+}
+
+// NewProgram creates a program from a copy of values
+func NewProgram(values []int) *Program {
+	mem := NewPagedMemory(values)
+
+	// Clone marks the pages shared, so every instance copies a page before writing to it
+	return &Program{mem.Clone()}
+}
+
+// ReadProgram reads a program from r in the format accepted by LoadReader
+func ReadProgram(r io.Reader) (*Program, error) {
+	values, err := parseProgram(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewProgram(values), nil
+}
+
+// ParseProgram parses a program from a string in the format accepted by LoadReader
+func ParseProgram(program string) (*Program, error) {
+	return ReadProgram(strings.NewReader(program))
+}
+
+// LoadProgram reads a program from file
+func LoadProgram(file string) (*Program, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	p, err := ReadProgram(f)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", file, err)
+	}
+
+	return p, nil
+}
+
+// Len returns the number of values in the program
+func (p *Program) Len() int {
+	return p.memory.Size()
+}
+
+// Get returns the value at addr, 0 if out of range
+func (p *Program) Get(addr int) int {
+	if addr < 0 {
+		return 0
+	}
+
+	return p.memory.Get(addr)
+}
+
+// Ints returns a copy of the values in the program
+func (p *Program) Ints() []int {
+	return p.memory.Cells()
+}
+
+// Instantiate creates a new intcode computer running program p
+func Instantiate(p *Program, wg *sync.WaitGroup, inputBufSize int, outputBufSize int) *IntCode {
+	ic := CreateWithMemory(wg, p.memory.Clone(), inputBufSize, outputBufSize)
+	ic.program = p
+
+	return ic
+}
+
+// Reload replaces the memory of an intcode with program p and resets it as Reset does. Later resets
+// return to p
+func Reload(ic *IntCode, p *Program) {
+	ic.program = p
+	Reset(ic)
+}
+
+// Reset returns an intcode to the program it was last loaded with: memory, program position, relative base,
+// instruction count, queued input and values waiting on its channels. The memory backend is kept. Paged and
+// sparse memory share pages with the program, so only pages written since are discarded. Returns ErrNoProgram
+// if the intcode was never loaded, such as one built with Set or Restore. The intcode must not be running
+func Reset(ic *IntCode) error {
+	p := ic.program
+	if p == nil {
+		return ErrNoProgram
+	}
+
+	if reflect.TypeOf(ic.memory) == reflect.TypeOf(p.memory) {
+		ic.memory = p.memory.Clone()
+	} else {
+		ic.memory.Replace(p.memory.Cells())
+	}
+
+	ic.programPos = 0
+	ic.relativeBase = 0
+	ic.steps = 0
+	ic.input = nil
+
+	drainChannels(ic)
+
+	return nil
+}
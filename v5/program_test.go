@@ -0,0 +1,214 @@
+package intcode
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestProgramInstances(t *testing.T) {
+	p, err := LoadProgram("./test_input/TstProg3")
+	if err != nil {
+		t.Fatalf(`TestProgramInstances: failed to load program: %v`, err)
+	}
+
+	results := make([]int, 50)
+	errs := make([]error, 50)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			ic := Instantiate(p, nil, 0, 0)
+			errs[i] = testRunCopy(ic)
+			results[i] = Get(ic, 5)
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range results {
+		if errs[i] != nil || results[i] != 9801 {
+			t.Fatalf(`TestProgramInstances: instance %v returned %v %v, want 9801`, i, results[i], errs[i])
+		}
+	}
+
+	want := []int{2, 4, 4, 5, 99, 0}
+	if p.Len() != len(want) {
+		t.Fatalf(`TestProgramInstances: program has %v values, want %v`, p.Len(), len(want))
+	}
+	for addr, value := range want {
+		if p.Get(addr) != value {
+			t.Fatalf(`TestProgramInstances: program address %v is %v, want %v`, addr, p.Get(addr), value)
+		}
+	}
+}
+
+func TestProgramReload(t *testing.T) {
+	p, err := ParseProgram("3,9,1,9,9,9,4,9,99,0")
+	if err != nil {
+		t.Fatalf(`TestProgramReload: failed to parse program: %v`, err)
+	}
+
+	ic := Instantiate(p, nil, 0, 0)
+	for _, input := range []int{4, 21} {
+		Feed(ic, input)
+		value, _, err := RunUntil(ic)
+		if err != nil || value != input*2 {
+			t.Fatalf(`TestProgramReload: input %v returned %v %v, want %v`, input, value, err, input*2)
+		}
+
+		Reload(ic, p)
+		if Pos(ic) != 0 || Steps(ic) != 0 || Get(ic, 9) != 0 {
+			t.Fatalf(`TestProgramReload: reload left position %v steps %v address 9 %v, want 0`, Pos(ic), Steps(ic), Get(ic, 9))
+		}
+	}
+}
+
+func TestProgramErrors(t *testing.T) {
+	var parseErr *ParseError
+
+	if _, err := ParseProgram("1,2,x"); !errors.As(err, &parseErr) || parseErr.Col != 5 {
+		t.Fatalf(`TestProgramErrors: returned %v, want ParseError at column 5`, err)
+	}
+	if _, err := LoadProgram("./test_input/TstProgMalformed"); !errors.As(err, &parseErr) || parseErr.Line != 2 {
+		t.Fatalf(`TestProgramErrors: returned %v, want ParseError at line 2`, err)
+	}
+	if _, err := CreateLoad(nil, "./test_input/Missing", 0, 0); err == nil {
+		t.Fatalf(`TestProgramErrors: missing file returned no error`)
+	}
+}
+
+func TestNewProgram(t *testing.T) {
+	values := []int{99}
+	p := NewProgram(values)
+	values[0] = 1
+	if p.Get(0) != 99 || p.Ints()[0] != 99 {
+		t.Fatalf(`TestNewProgram: program changed with its source`)
+	}
+}
+
+func TestReset(t *testing.T) {
+	const file = "./test_input/TstProgInputOutput2"
+	inputs := [][]int{{3, 4, 5, 6}, {1, 2, 7, 8}}
+
+	loaded := Create(nil, 0, 0)
+	if err := Load(loaded, file); err != nil {
+		t.Fatalf(`TestReset: failed to load program: %v`, err)
+	}
+	sparse := CreateWithMemory(nil, NewSparseMemory(nil), 0, 0)
+	if err := Load(sparse, file); err != nil {
+		t.Fatalf(`TestReset: failed to load program: %v`, err)
+	}
+	created, err := CreateLoad(nil, file, 4, 0)
+	if err != nil {
+		t.Fatalf(`TestReset: failed to load program: %v`, err)
+	}
+
+	for _, ic := range []*IntCode{loaded, sparse, created} {
+		for _, input := range inputs {
+			fresh, err := CreateLoad(nil, file, 0, 0)
+			if err != nil {
+				t.Fatalf(`TestReset: failed to load program: %v`, err)
+			}
+
+			want, err := testResetRun(fresh, input)
+			if err != nil {
+				t.Fatalf(`TestReset: fresh program returned error: %v`, err)
+			}
+			got, err := testResetRun(ic, input)
+			if err != nil {
+				t.Fatalf(`TestReset: reset program returned error: %v`, err)
+			}
+
+			if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+				t.Fatalf(`TestReset: returned %v, want %v`, got, want)
+			}
+			if Size(ic) != Size(fresh) || Steps(ic) != Steps(fresh) || Pos(ic) != Pos(fresh) {
+				t.Fatalf(`TestReset: size %v steps %v position %v, want %v %v %v`, Size(ic), Steps(ic), Pos(ic), Size(fresh), Steps(fresh), Pos(fresh))
+			}
+			for addr := 0; addr < Size(fresh); addr++ {
+				if Get(ic, addr) != Get(fresh, addr) {
+					t.Fatalf(`TestReset: address %v is %v, want %v`, addr, Get(ic, addr), Get(fresh, addr))
+				}
+			}
+
+			Feed(ic, 1)
+			if err := Reset(ic); err != nil {
+				t.Fatalf(`TestReset: reset returned error: %v`, err)
+			}
+		}
+	}
+
+	Write(created, 1)
+	if err := Reset(created); err != nil {
+		t.Fatalf(`TestReset: reset returned error: %v`, err)
+	}
+	select {
+	case value := <-created.inputChan:
+		t.Fatalf(`TestReset: input %v left on channel`, value)
+	default:
+	}
+
+	paged := created.memory.(*pagedMemory)
+	if paged.pages[0] != created.program.memory.(*pagedMemory).pages[0] || paged.pages[0].shared == 0 {
+		t.Fatalf(`TestReset: memory does not share pages with the program`)
+	}
+}
+
+func TestResetBackends(t *testing.T) {
+	backends := map[string]Memory{
+		"dense":  NewDenseMemory(nil),
+		"paged":  NewPagedMemory(nil),
+		"sparse": NewSparseMemory(nil),
+	}
+
+	for name, mem := range backends {
+		ic := CreateWithMemory(nil, mem, 0, 0)
+		if err := Reset(ic); err != ErrNoProgram {
+			t.Fatalf(`TestResetBackends: %v reset without a program returned %v, want ErrNoProgram`, name, err)
+		}
+
+		Set(ic, 3, 7)
+		if err := Reset(ic); err != ErrNoProgram || Get(ic, 3) != 7 {
+			t.Fatalf(`TestResetBackends: %v reset without a program returned %v and left %v, want ErrNoProgram and 7`, name, err, Get(ic, 3))
+		}
+
+		LoadInts(ic, []int{2, 4, 4, 5, 99, 0})
+		if err := testRunCopy(ic); err != nil {
+			t.Fatalf(`TestResetBackends: %v failed to run: %v`, name, err)
+		}
+		if err := Reset(ic); err != nil {
+			t.Fatalf(`TestResetBackends: %v reset returned error: %v`, name, err)
+		}
+
+		if reflect.TypeOf(ic.memory) != reflect.TypeOf(mem) {
+			t.Fatalf(`TestResetBackends: %v memory is %T after reset`, name, ic.memory)
+		}
+		if Get(ic, 5) != 0 || Size(ic) != 6 {
+			t.Fatalf(`TestResetBackends: %v address 5 is %v size %v after reset, want 0 size 6`, name, Get(ic, 5), Size(ic))
+		}
+	}
+}
+
+func testResetRun(ic *IntCode, inputs []int) ([]int, error) {
+	for _, input := range inputs {
+		Feed(ic, input)
+	}
+
+	outputs := make([]int, 0)
+	for {
+		value, state, err := RunUntil(ic)
+		if err != nil {
+			return nil, err
+		}
+
+		switch state {
+		case StateOutput:
+			outputs = append(outputs, value)
+		case StateHalted:
+			return outputs, nil
+		}
+	}
+}
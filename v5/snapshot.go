@@ -0,0 +1,272 @@
+package intcode
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// SnapshotFormat is the encoding of a snapshot
+type SnapshotFormat int
+
+const (
+	// SnapshotBinary is a compact binary encoding
+	SnapshotBinary SnapshotFormat = iota
+	// SnapshotJSON is a JSON object
+	SnapshotJSON
+)
+
+// snapshotVersion is the version of the snapshot layout written by Snapshot. Version 1 has no pending channel
+// values and versions 1 and 2 store memory as one list instead of segments
+const snapshotVersion = 3
+
+// snapshotMagic starts every binary snapshot
+const snapshotMagic = "ICSNAP"
+
+// snapshotName identifies JSON snapshots
+const snapshotName = "intcode-snapshot"
+
+// snapshot is the state saved by Snapshot
+type snapshot struct {
+	Format       string    `json:"format"`
+	Version      int       `json:"version"`
+	ProgramPos   int       `json:"program_pos"`
+	RelativeBase int       `json:"relative_base"`
+	Steps        int       `json:"steps"`
+	Input        []int     `json:"input"`
+	Memory       []int     `json:"memory,omitempty"`
+	Size         int       `json:"size"`
+	Segments     []segment `json:"segments"`
+	ChanInput    []int     `json:"chan_input"`
+	ChanOutput   []int     `json:"chan_output"`
+}
+
+// segment is a run of memory cells in a snapshot. Cells not in a segment are 0
+type segment struct {
+	Addr   int   `json:"addr"`
+	Values []int `json:"values"`
+}
+
+// Snapshot writes the state of an intcode to w: memory, program position, relative base, instruction
+// count, queued input and values buffered on its input and output channels. The snapshot records its format
+// and version so Restore can read either encoding. The intcode must not be running
+func Snapshot(ic *IntCode, w io.Writer, format SnapshotFormat) error {
+	snap := snapshot{
+		Format:       snapshotName,
+		Version:      snapshotVersion,
+		ProgramPos:   ic.programPos,
+		RelativeBase: ic.relativeBase,
+		Steps:        ic.steps,
+		Input:        append([]int{}, ic.input...),
+		Size:         ic.memory.Size(),
+		Segments:     segments(ic.memory),
+		ChanInput:    bufferedValues(ic.inputChan),
+		ChanOutput:   bufferedValues(ic.outputChan),
+	}
+
+	switch format {
+	case SnapshotBinary:
+		return writeBinarySnapshot(w, snap)
+	case SnapshotJSON:
+		return json.NewEncoder(w).Encode(snap)
+	default:
+		return fmt.Errorf("Unknown snapshot format %v", format)
+	}
+}
+
+// Restore replaces the state of an intcode with a snapshot read from r in either format
+func Restore(ic *IntCode, r io.Reader) error {
+	br := bufio.NewReader(r)
+
+	var snap snapshot
+	magic, err := br.Peek(len(snapshotMagic))
+	if err == nil && string(magic) == snapshotMagic {
+		snap, err = readBinarySnapshot(br)
+	} else {
+		err = json.NewDecoder(br).Decode(&snap)
+		if err == nil && snap.Format != snapshotName {
+			err = errors.New("Not an intcode snapshot")
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to read snapshot: %w", err)
+	}
+
+	if snap.Version < 1 || snap.Version > snapshotVersion {
+		return fmt.Errorf("Unsupported snapshot version %v", snap.Version)
+	}
+	if snap.Version < 3 {
+		snap.Size = len(snap.Memory)
+		snap.Segments = []segment{{0, snap.Memory}}
+	}
+	if snap.Size < 0 {
+		return fmt.Errorf("Snapshot memory size %v is negative", snap.Size)
+	}
+	if ic.maxMemory > 0 && snap.Size > ic.maxMemory {
+		return &BudgetError{Steps: ic.steps, MaxSteps: ic.maxSteps, Memory: snap.Size, MaxMemory: ic.maxMemory}
+	}
+	for _, seg := range snap.Segments {
+		if seg.Addr < 0 || seg.Addr+len(seg.Values) > snap.Size {
+			return fmt.Errorf("Snapshot segment at %v of %v values is outside memory size %v", seg.Addr, len(seg.Values), snap.Size)
+		}
+	}
+	if len(snap.ChanInput) > cap(ic.inputChan) || len(snap.ChanOutput) > cap(ic.outputChan) {
+		return fmt.Errorf("Snapshot has %v input and %v output channel values but the channels hold %v and %v",
+			len(snap.ChanInput), len(snap.ChanOutput), cap(ic.inputChan), cap(ic.outputChan))
+	}
+
+	drainChannels(ic)
+	for _, value := range snap.ChanInput {
+		ic.inputChan <- value
+	}
+	for _, value := range snap.ChanOutput {
+		ic.outputChan <- value
+	}
+
+	ic.memory.Replace(nil)
+	if snap.Size > 0 {
+		ic.memory.Set(snap.Size-1, 0)
+	}
+	for _, seg := range snap.Segments {
+		for i, value := range seg.Values {
+			ic.memory.Set(seg.Addr+i, value)
+		}
+	}
+	ic.programPos = snap.ProgramPos
+	ic.relativeBase = snap.RelativeBase
+	ic.steps = snap.Steps
+	ic.input = snap.Input
+
+	return nil
+}
+
+func writeBinarySnapshot(w io.Writer, snap snapshot) error {
+	var buf bytes.Buffer
+	buf.WriteString(snapshotMagic)
+
+	varint := make([]byte, binary.MaxVarintLen64)
+	put := func(value int) {
+		n := binary.PutVarint(varint, int64(value))
+		buf.Write(varint[:n])
+	}
+
+	putList := func(values []int) {
+		put(len(values))
+		for _, value := range values {
+			put(value)
+		}
+	}
+
+	put(snap.Version)
+	put(snap.ProgramPos)
+	put(snap.RelativeBase)
+	put(snap.Steps)
+	putList(snap.Input)
+	put(snap.Size)
+	put(len(snap.Segments))
+	for _, seg := range snap.Segments {
+		put(seg.Addr)
+		putList(seg.Values)
+	}
+	putList(snap.ChanInput)
+	putList(snap.ChanOutput)
+
+	_, err := buf.WriteTo(w)
+
+	return err
+}
+
+func readBinarySnapshot(r *bufio.Reader) (snapshot, error) {
+	snap := snapshot{Format: snapshotName}
+
+	if _, err := r.Discard(len(snapshotMagic)); err != nil {
+		return snap, err
+	}
+
+	var err error
+	get := func() int {
+		if err != nil {
+			return 0
+		}
+		var value int64
+		value, err = binary.ReadVarint(r)
+		return int(value)
+	}
+	getList := func() []int {
+		n := get()
+		if err == nil && n < 0 {
+			err = fmt.Errorf("Invalid list length %v", n)
+		}
+		values := make([]int, 0)
+		for i := 0; i < n && err == nil; i++ {
+			values = append(values, get())
+		}
+		return values
+	}
+
+	snap.Version = get()
+	if err == nil && (snap.Version < 1 || snap.Version > snapshotVersion) {
+		return snap, nil
+	}
+	snap.ProgramPos = get()
+	snap.RelativeBase = get()
+	snap.Steps = get()
+	snap.Input = getList()
+	if snap.Version < 3 {
+		snap.Memory = getList()
+	} else {
+		snap.Size = get()
+		n := get()
+		if err == nil && n < 0 {
+			err = fmt.Errorf("Invalid segment count %v", n)
+		}
+		for i := 0; i < n && err == nil; i++ {
+			addr := get()
+			snap.Segments = append(snap.Segments, segment{addr, getList()})
+		}
+	}
+	if snap.Version >= 2 {
+		snap.ChanInput = getList()
+		snap.ChanOutput = getList()
+	}
+
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+
+	return snap, err
+}
+
+// bufferedValues returns the values buffered on ch, leaving them buffered in order
+func bufferedValues(ch chan int) []int {
+	values := make([]int, len(ch))
+	for i := range values {
+		values[i] = <-ch
+	}
+
+	for _, value := range values {
+		ch <- value
+	}
+
+	return values
+}
+
+// segments returns the runs of cells stored in mem, joining runs which follow on from each other
+func segments(mem Memory) []segment {
+	segs := make([]segment, 0)
+
+	mem.Range(func(addr int, values []int) bool {
+		if n := len(segs); n > 0 && segs[n-1].Addr+len(segs[n-1].Values) == addr {
+			segs[n-1].Values = append(segs[n-1].Values, values...)
+		} else {
+			segs = append(segs, segment{addr, append([]int(nil), values...)})
+		}
+		return true
+	})
+
+	return segs
+}
@@ -0,0 +1,127 @@
+package intcode
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	for _, format := range []SnapshotFormat{SnapshotBinary, SnapshotJSON} {
+		ic, err := CreateLoad(new(sync.WaitGroup), "./test_input/TstProgInputOutput2", 0, 0)
+		if err != nil {
+			t.Fatalf(`TestSnapshotRestore: failed to load program: %v`, err)
+		}
+
+		Feed(ic, 5, 3, 10)
+		value, state, err := RunUntil(ic)
+		if err != nil || state != StateOutput || value != 8 {
+			t.Fatalf(`TestSnapshotRestore: returned (%v, %v, %v), want (8, %v, nil)`, value, state, err, StateOutput)
+		}
+
+		var buf bytes.Buffer
+		if err := Snapshot(ic, &buf, format); err != nil {
+			t.Fatalf(`TestSnapshotRestore: format %v returned error: %v`, format, err)
+		}
+
+		restored := Create(new(sync.WaitGroup), 0, 0)
+		if err := Restore(restored, &buf); err != nil {
+			t.Fatalf(`TestSnapshotRestore: format %v restore returned error: %v`, format, err)
+		}
+
+		if Pos(restored) != Pos(ic) || Steps(restored) != Steps(ic) || Size(restored) != Size(ic) {
+			t.Fatalf(`TestSnapshotRestore: format %v restored pos %v steps %v size %v, want %v %v %v`, format,
+				Pos(restored), Steps(restored), Size(restored), Pos(ic), Steps(ic), Size(ic))
+		}
+
+		// The queued 10 survives the snapshot so only the last input is needed
+		Feed(restored, 4)
+		value, state, err = RunUntil(restored)
+		if err != nil || state != StateOutput || value != 40 {
+			t.Fatalf(`TestSnapshotRestore: format %v returned (%v, %v, %v), want (40, %v, nil)`, format, value, state, err, StateOutput)
+		}
+	}
+}
+
+func TestRestoreInvalid(t *testing.T) {
+	ic := Create(new(sync.WaitGroup), 0, 0)
+
+	for _, data := range []string{
+		`{"format":"something-else","version":1}`,
+		`{"format":"intcode-snapshot","version":99}`,
+		`{"format":"intcode-snapshot","version":3,"size":-5}`,
+		"ICSNAP\x02",
+		"ICSNAP\x02\x00\x00",
+		"not a snapshot",
+	} {
+		if err := Restore(ic, strings.NewReader(data)); err == nil {
+			t.Fatalf(`TestRestoreInvalid: restored %q without error`, data)
+		}
+	}
+}
+
+func TestRestoreBudget(t *testing.T) {
+	ic := Create(new(sync.WaitGroup), 0, 0)
+	SetMaxMemory(ic, 100)
+
+	err := Restore(ic, strings.NewReader(`{"format":"intcode-snapshot","version":3,"size":1000000}`))
+
+	var budget *BudgetError
+	if !errors.As(err, &budget) || budget.Memory != 1000000 || budget.MaxMemory != 100 {
+		t.Fatalf(`TestRestoreBudget: returned %v, want budget error for 1000000 of 100`, err)
+	}
+	if Size(ic) != 0 {
+		t.Fatalf(`TestRestoreBudget: grew memory to %v, want 0`, Size(ic))
+	}
+}
+
+func TestSnapshotChannels(t *testing.T) {
+	for _, format := range []SnapshotFormat{SnapshotBinary, SnapshotJSON} {
+		ic, err := CreateLoad(nil, "./test_input/TstProgInputOutput2", 4, 2)
+		if err != nil {
+			t.Fatalf(`TestSnapshotChannels: failed to load program: %v`, err)
+		}
+		Write(ic, 5)
+		Write(ic, 3)
+		ic.outputChan <- 99
+
+		var buf bytes.Buffer
+		if err := Snapshot(ic, &buf, format); err != nil {
+			t.Fatalf(`TestSnapshotChannels: format %v returned error: %v`, format, err)
+		}
+		if len(ic.inputChan) != 2 || len(ic.outputChan) != 1 {
+			t.Fatalf(`TestSnapshotChannels: format %v left %v inputs and %v outputs buffered, want 2 and 1`, format, len(ic.inputChan), len(ic.outputChan))
+		}
+
+		restored := Create(nil, 4, 2)
+		if err := Restore(restored, bytes.NewReader(buf.Bytes())); err != nil {
+			t.Fatalf(`TestSnapshotChannels: format %v restore returned error: %v`, format, err)
+		}
+		if len(restored.inputChan) != 2 || <-restored.inputChan != 5 || <-restored.inputChan != 3 || <-restored.outputChan != 99 {
+			t.Fatalf(`TestSnapshotChannels: format %v did not restore channel values`, format)
+		}
+
+		small := Create(nil, 1, 0)
+		if err := Restore(small, bytes.NewReader(buf.Bytes())); err == nil {
+			t.Fatalf(`TestSnapshotChannels: format %v restored into channels too small without error`, format)
+		}
+	}
+}
+
+func TestRestoreVersion1(t *testing.T) {
+	ic := Create(nil, 0, 0)
+
+	for _, data := range []string{
+		`{"format":"intcode-snapshot","version":1,"program_pos":2,"input":[7],"memory":[3,0,4,0,99]}`,
+		"ICSNAP\x02\x04\x00\x00\x02\x0e\x0a\x06\x00\x08\x00\xc6\x01",
+	} {
+		if err := Restore(ic, strings.NewReader(data)); err != nil {
+			t.Fatalf(`TestRestoreVersion1: %q returned error: %v`, data, err)
+		}
+		if Pos(ic) != 2 || Size(ic) != 5 || Get(ic, 4) != 99 || len(ic.input) != 1 || ic.input[0] != 7 {
+			t.Fatalf(`TestRestoreVersion1: %q restored pos %v size %v input %v`, data, Pos(ic), Size(ic), ic.input)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package intcode
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Event describes an instruction executed by an intcode computer
+type Event struct {
+	// Addr is the address of the instruction
+	Addr int `json:"addr"`
+	// Opcode is the raw opcode including parameter modes
+	Opcode int `json:"opcode"`
+	// Op is the mnemonic of the operation
+	Op string `json:"op"`
+	// Params are the raw parameter values
+	Params []int `json:"params"`
+	// Modes are the parameter modes
+	Modes []ParamMode `json:"modes"`
+	// Values are the resolved parameter values. The value of a written parameter is the value written
+	Values []int `json:"values"`
+	// Target is the address written to or -1 if the instruction does not write memory
+	Target int `json:"target"`
+	// RelativeBase is the relative base before the instruction executed
+	RelativeBase int `json:"relative_base"`
+}
+
+// Tracer receives an event for each instruction an intcode executes
+type Tracer interface {
+	Trace(event Event)
+}
+
+// TextTracer writes one line of text per event
+type TextTracer struct {
+	w io.Writer
+}
+
+// JSONTracer writes one JSON object per line per event
+type JSONTracer struct {
+	enc *json.Encoder
+}
+
+// RingTracer keeps the most recent events in memory
+type RingTracer struct {
+	mutex  sync.Mutex
+	events []Event
+	next   int
+	full   bool
+}
+
+// NewTextTracer creates a tracer writing text lines to w
+func NewTextTracer(w io.Writer) *TextTracer {
+	return &TextTracer{w}
+}
+
+// Trace writes an event as a line of text
+func (t *TextTracer) Trace(event Event) {
+	params := make([]string, len(event.Params))
+	for i, param := range event.Params {
+		params[i] = fmt.Sprintf("%v mode %v", param, event.Modes[i])
+	}
+
+	line := fmt.Sprintf("[%v, %v] %v (%v) %v", event.Addr, event.RelativeBase, strings.ToUpper(event.Op),
+		strings.Join(params, ", "), event.Values)
+	if event.Target >= 0 {
+		line += fmt.Sprintf(" => 0x%v", event.Target)
+	}
+
+	fmt.Fprintln(t.w, line)
+}
+
+// NewJSONTracer creates a tracer writing JSON Lines to w
+func NewJSONTracer(w io.Writer) *JSONTracer {
+	return &JSONTracer{json.NewEncoder(w)}
+}
+
+// Trace writes an event as a JSON object on its own line
+func (t *JSONTracer) Trace(event Event) {
+	t.enc.Encode(event)
+}
+
+// NewRingTracer creates a tracer keeping the last size events. A size below 1 records nothing
+func NewRingTracer(size int) *RingTracer {
+	if size < 0 {
+		size = 0
+	}
+
+	return &RingTracer{events: make([]Event, size)}
+}
+
+// Trace records an event, discarding the oldest if the ring is full
+func (t *RingTracer) Trace(event Event) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if len(t.events) == 0 {
+		return
+	}
+
+	t.events[t.next] = event
+	t.next++
+	if t.next == len(t.events) {
+		t.next = 0
+		t.full = true
+	}
+}
+
+// Events returns the recorded events from oldest to newest
+func (t *RingTracer) Events() []Event {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if !t.full {
+		return append([]Event(nil), t.events[:t.next]...)
+	}
+
+	return append(append([]Event(nil), t.events[t.next:]...), t.events[:t.next]...)
+}
+
+// trace sends an event for the instruction at addr to the intcode's tracer
+func trace(ic *IntCode, addr int, fullOp int, params []int, values []int, target int) {
+	event := Event{
+		Addr:         addr,
+		Opcode:       fullOp,
+		Op:           opTable[fullOp%100].Name,
+		Params:       params,
+		Modes:        make([]ParamMode, len(params)),
+		Values:       values,
+		Target:       target,
+		RelativeBase: ic.relativeBase,
+	}
+	for i := range params {
+		event.Modes[i] = getParamMode(fullOp, i)
+	}
+
+	ic.tracer.Trace(event)
+}
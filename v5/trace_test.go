@@ -0,0 +1,84 @@
+package intcode
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRingTracer(t *testing.T) {
+	ic, err := CreateLoad(new(sync.WaitGroup), "./test_input/TstProgInputOutput2", 0, 0)
+	if err != nil {
+		t.Fatalf(`TestRingTracer: failed to load program: %v`, err)
+	}
+
+	ring := NewRingTracer(3)
+	SetTracer(ic, ring)
+	Feed(ic, 5, 3, 10, 4)
+
+	for {
+		_, state, err := RunUntil(ic)
+		if err != nil {
+			t.Fatalf(`TestRingTracer: returned error: %v`, err)
+		} else if state == StateHalted {
+			break
+		}
+	}
+
+	events := ring.Events()
+	if len(events) != 3 {
+		t.Fatalf(`TestRingTracer: recorded %v events, want 3`, len(events))
+	}
+
+	mul := events[0]
+	if mul.Addr != 14 || mul.Op != "mul" || mul.Target != 14 || mul.Values[0] != 10 || mul.Values[1] != 4 || mul.Values[2] != 40 {
+		t.Fatalf(`TestRingTracer: returned %+v, want mul of 10 and 4 into 14`, mul)
+	}
+	if events[1].Op != "out" || events[1].Target != -1 || events[2].Op != "hlt" {
+		t.Fatalf(`TestRingTracer: returned %+v, want out then hlt`, events[1:])
+	}
+}
+
+func TestJSONTracer(t *testing.T) {
+	ic, err := CreateLoad(new(sync.WaitGroup), "./test_input/TstProgInputOutput", 0, 0)
+	if err != nil {
+		t.Fatalf(`TestJSONTracer: failed to load program: %v`, err)
+	}
+
+	var out strings.Builder
+	SetTracer(ic, NewJSONTracer(&out))
+	Feed(ic, 7)
+
+	RunUntil(ic)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf(`TestJSONTracer: wrote %q, want 2 lines`, out.String())
+	}
+
+	var event Event
+	if err := json.Unmarshal([]byte(lines[0]), &event); err != nil {
+		t.Fatalf(`TestJSONTracer: wrote invalid JSON %q: %v`, lines[0], err)
+	}
+	if event.Op != "in" || event.Values[0] != 7 || event.Target != 0 {
+		t.Fatalf(`TestJSONTracer: returned %+v, want input of 7 into 0`, event)
+	}
+}
+
+func TestRingTracerEmpty(t *testing.T) {
+	ic, err := CreateLoad(new(sync.WaitGroup), "./test_input/TstProgInputOutput", 0, 0)
+	if err != nil {
+		t.Fatalf(`TestRingTracerEmpty: failed to load program: %v`, err)
+	}
+
+	ring := NewRingTracer(-1)
+	SetTracer(ic, ring)
+	Feed(ic, 7)
+
+	RunUntil(ic)
+
+	if events := ring.Events(); len(events) != 0 {
+		t.Fatalf(`TestRingTracerEmpty: recorded %+v, want nothing`, events)
+	}
+}